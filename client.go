@@ -0,0 +1,177 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"net/http"
+	"time"
+
+	api "google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// A Client reads and writes events through the Google Calendar API.
+type Client struct {
+	svc *api.Service
+	// Attempts per request, counting the first, for errors that may be
+	// temporary.
+	attempts   int
+	backoff    time.Duration // before the first retry; doubles after each
+	middleware []Middleware
+}
+
+// NewClientFromService returns a Client that uses svc.
+func NewClientFromService(svc *api.Service) *Client {
+	return &Client{svc: svc, attempts: 4, backoff: time.Second}
+}
+
+// Service returns the Calendar API service used by c, for calls that
+// Client doesn't wrap.
+func (c *Client) Service() *api.Service {
+	return c.svc
+}
+
+// A Query selects the events that Client.Events returns.
+type Query struct {
+	CalendarID string    // "primary" if empty
+	From, To   time.Time // zero for no limit
+	Text       string    // free text to search for, as in the Calendar UI
+
+	// SyncToken, from an earlier NextSyncToken, makes Events return only
+	// what has changed since then. Deleted events are returned with
+	// Status "cancelled" and only their ID set. It can't be combined with
+	// From, To or Text; ErrSyncTokenExpired means a full listing is needed.
+	SyncToken string
+
+	// If non-nil, NextSyncToken is set when the iteration finishes to the
+	// token for the next incremental sync. Events are then returned in no
+	// particular order, instead of by start time.
+	NextSyncToken *string
+}
+
+// ErrSyncTokenExpired is returned by Events when the server no longer
+// accepts Query.SyncToken.
+var ErrSyncTokenExpired = errors.New("calendar: sync token expired")
+
+// Events returns the events matching q. Recurring events are expanded
+// into their instances. Pages are fetched as the iteration needs them,
+// and requests are retried when the error may be temporary. An error
+// ends the iteration.
+func (c *Client) Events(ctx context.Context, q Query) iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		calID := q.CalendarID
+		if calID == "" {
+			calID = "primary"
+		}
+		sync := q.SyncToken != "" || q.NextSyncToken != nil
+		if q.SyncToken != "" && (!q.From.IsZero() || !q.To.IsZero() || q.Text != "") {
+			yield(Event{}, errors.New("calendar: SyncToken can't be combined with From, To or Text"))
+			return
+		}
+		pageToken := ""
+		for {
+			call := c.svc.Events.List(calID).Context(ctx).SingleEvents(true)
+			if !sync {
+				call.OrderBy("startTime")
+			}
+			if q.SyncToken != "" {
+				call.SyncToken(q.SyncToken)
+			}
+			if !q.From.IsZero() {
+				call.TimeMin(q.From.Format(time.RFC3339))
+			}
+			if !q.To.IsZero() {
+				call.TimeMax(q.To.Format(time.RFC3339))
+			}
+			if q.Text != "" {
+				call.Q(q.Text)
+			}
+			if pageToken != "" {
+				call.PageToken(pageToken)
+			}
+			var page *api.Events
+			err := c.retry(ctx, temporary, func() error {
+				var err error
+				page, err = call.Do()
+				return err
+			})
+			var gerr *googleapi.Error
+			if errors.As(err, &gerr) && gerr.Code == http.StatusGone && q.SyncToken != "" {
+				err = ErrSyncTokenExpired
+			}
+			if err != nil {
+				yield(Event{}, err)
+				return
+			}
+			for _, ae := range page.Items {
+				if ae.Status == "cancelled" && sync {
+					if !yield(Event{ID: ae.Id, Status: ae.Status}, nil) {
+						return
+					}
+					continue
+				}
+				e, err := FromAPI(ae)
+				if err != nil {
+					yield(Event{}, err)
+					return
+				}
+				if !yield(*e, nil) {
+					return
+				}
+			}
+			if page.NextPageToken == "" {
+				if q.NextSyncToken != nil {
+					*q.NextSyncToken = page.NextSyncToken
+				}
+				return
+			}
+			pageToken = page.NextPageToken
+		}
+	}
+}
+
+// retry calls f until it succeeds, fails with an error that retryable
+// rejects, or has been called c.attempts times.
+func (c *Client) retry(ctx context.Context, retryable func(error) bool, f func() error) error {
+	wait := c.backoff
+	for i := 1; ; i++ {
+		err := f()
+		if err == nil || i >= c.attempts || !retryable(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// temporary reports whether err may go away if the request is repeated:
+// rate limiting and server errors.
+func temporary(err error) bool {
+	var gerr *googleapi.Error
+	return rateLimited(err) || errors.As(err, &gerr) && gerr.Code >= 500
+}
+
+// rateLimited reports whether err is the server refusing a request
+// because of rate limits. The request wasn't carried out, so it is safe
+// to repeat.
+func rateLimited(err error) bool {
+	var gerr *googleapi.Error
+	if !errors.As(err, &gerr) {
+		return false
+	}
+	if gerr.Code == http.StatusTooManyRequests {
+		return true
+	}
+	// Calendar reports some rate limiting as 403.
+	for _, e := range gerr.Errors {
+		if e.Reason == "rateLimitExceeded" || e.Reason == "userRateLimitExceeded" {
+			return true
+		}
+	}
+	return false
+}
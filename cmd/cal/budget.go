@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	api "google.golang.org/api/calendar/v3"
+)
+
+// eventColors maps the names the Calendar UI gives event colors to their
+// color IDs. They are not the same as the calendar colors.
+var eventColors = map[string]string{
+	"lavender":  "1",
+	"sage":      "2",
+	"grape":     "3",
+	"flamingo":  "4",
+	"banana":    "5",
+	"tangerine": "6",
+	"peacock":   "7",
+	"graphite":  "8",
+	"blueberry": "9",
+	"basil":     "10",
+	"tomato":    "11",
+}
+
+// A budget limits the time that events of one kind can take each day or
+// week. It comes from the [budgets] section of the config file:
+//
+//	tomato = "10h/week"
+//	deep work = "<= 4h/day"
+//
+// A key that is an event color matches events of that color, as set by
+// a color field in the text, YAML or CSV formats; any other key matches
+// events whose summary contains it, ignoring case.
+type budget struct {
+	name   string
+	color  string // color ID, or "" to match the summary
+	limit  time.Duration
+	period int // days
+}
+
+// budgets are the budgets of the config file, sorted by name.
+var budgets []budget
+
+// parseBudgets parses the [budgets] section of the config file.
+func parseBudgets(section map[string]string) ([]budget, error) {
+	var bs []budget
+	for name, v := range section {
+		b := budget{name: name, color: eventColors[strings.ToLower(name)]}
+		v = strings.TrimSpace(strings.TrimPrefix(v, "<="))
+		d, per, ok := strings.Cut(v, "/")
+		if !ok {
+			return nil, fmt.Errorf("budget %q: want a limit like 10h/week, got %q", name, v)
+		}
+		switch strings.TrimSpace(per) {
+		case "day":
+			b.period = 1
+		case "week":
+			b.period = 7
+		default:
+			return nil, fmt.Errorf("budget %q: want /day or /week, got /%s", name, per)
+		}
+		var err error
+		if b.limit, err = time.ParseDuration(strings.TrimSpace(d)); err != nil {
+			return nil, fmt.Errorf("budget %q: %v", name, err)
+		}
+		bs = append(bs, b)
+	}
+	sort.Slice(bs, func(i, j int) bool { return bs[i].name < bs[j].name })
+	return bs, nil
+}
+
+func (b budget) matches(e *api.Event) bool {
+	if b.color != "" {
+		return e.ColorId == b.color
+	}
+	return strings.Contains(strings.ToLower(e.Summary), strings.ToLower(b.name))
+}
+
+// periodStart returns the start of the budget period containing t: its
+// day, or the Monday of its week, in inputLoc.
+func (b budget) periodStart(t time.Time) time.Time {
+	if b.period == 7 {
+		return weekStart(t)
+	}
+	t = t.In(inputLoc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, inputLoc)
+}
+
+// budgetUse returns the time that the events among evs matching b take in
+// each of b's periods, by the start of the period.
+func budgetUse(b budget, evs []*api.Event) map[time.Time]time.Duration {
+	use := map[time.Time]time.Duration{}
+	for _, e := range evs {
+		if !b.matches(e) {
+			continue
+		}
+		if m, ok := eventMeeting(e); ok {
+			use[b.periodStart(m.start)] += m.end.Sub(m.start)
+		}
+	}
+	return use
+}
+
+// warnBudgets logs a warning for each budget that adding evs to the
+// calendar would exceed.
+func warnBudgets(ctx context.Context, c *api.Service, calID string, evs []*api.Event) error {
+	for _, b := range budgets {
+		added := budgetUse(b, evs)
+		var starts []time.Time
+		for p := range added {
+			starts = append(starts, p)
+		}
+		sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+		for _, p := range starts {
+			var existing []*api.Event
+			q := listQuery{from: p, to: p.AddDate(0, 0, b.period)}
+			err := eachEvent(ctx, c, calID, q, func(e *api.Event) error {
+				existing = append(existing, e)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			now := budgetUse(b, existing)[p]
+			if now+added[p] > b.limit {
+				slog.Warn(fmt.Sprintf("%s: budget %q: %s would have %s (%s now), over %s",
+					calID, b.name, periodName(p, b.period), now+added[p], now, b.limit))
+			}
+		}
+	}
+	return nil
+}
+
+func periodName(start time.Time, days int) string {
+	if days == 7 {
+		return "the week of " + start.Format("Jan 2")
+	}
+	return start.Format("Mon Jan 2")
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cachingTransport caches the responses to GET requests that carry an
+// ETag, and revalidates them with If-None-Match. A 304 Not Modified is
+// answered from the cache, so the caller sees the full response, with
+// cachedHeader added.
+type cachingTransport struct {
+	base http.RoundTripper
+	dir  string
+}
+
+// newCachingTransport returns a cachingTransport whose entries are kept
+// apart from those of other credentials, because the same URL (say, the
+// "primary" calendar) means different things to different users.
+func newCachingTransport(base http.RoundTripper, creds []byte) *cachingTransport {
+	dir := cacheDir()
+	if dir == "" {
+		return nil
+	}
+	return &cachingTransport{
+		base: base,
+		dir:  filepath.Join(dir, "http", fmt.Sprintf("%x", sha256.Sum256(creds))[:16]),
+	}
+}
+
+// cachedHeader, on a response answered from the cache, holds the RFC 3339
+// time at which the response was stored: the contents haven't changed
+// since then.
+const cachedHeader = "X-Cal-Cached"
+
+func (t *cachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return t.base.RoundTrip(req)
+	}
+	file := filepath.Join(t.dir, fmt.Sprintf("%x", sha256.Sum256([]byte(req.URL.String()))))
+	cached := readCachedResponse(file, req)
+	if cached != nil && req.Header.Get("If-None-Match") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", cached.Header.Get("ETag"))
+	}
+	res, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusNotModified && cached != nil {
+		res.Body.Close()
+		if fi, err := os.Stat(file); err == nil {
+			cached.Header.Set(cachedHeader, fi.ModTime().Format(time.RFC3339))
+		}
+		return cached, nil
+	}
+	if res.StatusCode != http.StatusOK || res.Header.Get("ETag") == "" {
+		return res, nil
+	}
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	dump, err := httputil.DumpResponse(res, true)
+	if err == nil {
+		err = writeCacheFile(file, dump)
+	}
+	if err != nil {
+		slog.Warn(fmt.Sprintf("caching %s: %v", req.URL.Redacted(), err))
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	return res, nil
+}
+
+// readCachedResponse returns the response stored in file, or nil.
+func readCachedResponse(file string, req *http.Request) *http.Response {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	res, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), req)
+	if err != nil || res.Header.Get("ETag") == "" {
+		return nil
+	}
+	return res
+}
+
+func writeCacheFile(file string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return err
+	}
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
@@ -1,3 +1,10 @@
+// Command cal manages Google Calendar events from the command line.
+//
+// Usage:
+//
+//	cal command [flags]
+//
+// Run "cal help" for the list of commands.
 package main
 
 import (
@@ -6,180 +13,601 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"net/http"
+	"os"
+	"os/exec"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/jba/calendar"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	api "google.golang.org/api/calendar/v3"
-	"google.golang.org/api/option"
-	htrans "google.golang.org/api/transport/http"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/gmail/v1"
 )
 
+// Flags shared by all commands. They are added to each command's flag set.
 var (
-	credsFile  = flag.String("creds", "", "filename for creds")
-	id         = flag.String("id", "", "ID of calendar (typically, user email address)")
-	eventFile  = flag.String("events", "", "filename of events")
-	startIndex = flag.Int("start", 1, "1-based event to start inserting at")
-	endIndex   = flag.Int("end", -1, "1-based event to end inserting at, inclusive")
-	doit       = flag.Bool("doit", false, "nothing happens unless this is provided")
+	credsFile string
+	credsCmd  string
+	calID     string // the first of calIDs
+	calIDs    idList
+	calRefs   []calRef // calIDs, parsed
+	idAccount string   // the account of calID
+	displayTZ string
+	inputTZ   string
+	nowFlag   string
+	readOnly  bool
+	noCache   bool
+	// Used through cacheDir.
+	cacheDirFlag string
 )
 
-func main() {
-	flag.Parse()
-	ctx := context.Background()
-	if *credsFile == "" {
-		log.Fatal("need -creds")
+// If non-nil, the location in which to display event times.
+var displayLoc *time.Location
+
+// If non-zero, the current time, from -now.
+var fixedNow time.Time
+
+// now returns the current time, or the time given by -now.
+func now() time.Time {
+	if !fixedNow.IsZero() {
+		return fixedNow
 	}
-	if *id == "" {
-		log.Fatal("need -id")
+	return time.Now()
+}
+
+type command struct {
+	name     string
+	args     string // synopsis of the arguments after the flags
+	help     string
+	flags    *flag.FlagSet
+	run      func(ctx context.Context, args []string)
+	noCreds  bool // the command doesn't use -creds
+	usesID   bool // the command has an -id flag
+	needsID  bool // the command requires -id
+	multiID  bool // the command can take more than one -id
+	ownCreds bool // the command checks for the creds it needs itself
+	hidden   bool // the command isn't listed by usage
+}
+
+var commands = []*command{
+	{
+		name:    "insert",
+		help:    "insert events from a file",
+		flags:   insertFlags,
+		run:     runInsert,
+		usesID:  true,
+		multiID: true,
+	},
+	{
+		name:    "quickadd",
+		args:    "text",
+		help:    "create an event from text like \"Lunch with Ann Friday at noon\"",
+		flags:   quickaddFlags,
+		run:     runQuickAdd,
+		usesID:  true,
+		needsID: true,
+	},
+	{
+		name:    "list",
+		help:    "list events, by default the upcoming ones",
+		flags:   listFlags,
+		run:     runList,
+		needsID: true,
+		usesID:  true,
+		multiID: true,
+	},
+	{
+		name:    "show",
+		args:    "event-id...",
+		help:    "show all the details of events",
+		flags:   showFlags,
+		run:     runShow,
+		usesID:  true,
+		needsID: true,
+	},
+	{
+		name:    "search",
+		args:    "[query]",
+		help:    "find events by text, printing their IDs",
+		flags:   searchFlags,
+		run:     runSearch,
+		usesID:  true,
+		needsID: true,
+	},
+	{
+		name:    "delete",
+		args:    "event-id...",
+		help:    "delete events by ID",
+		flags:   deleteFlags,
+		run:     runDelete,
+		usesID:  true,
+		needsID: true,
+	},
+	{
+		name:    "move",
+		args:    "event-id...",
+		help:    "move events to another calendar",
+		flags:   moveFlags,
+		run:     runMove,
+		usesID:  true,
+		needsID: true,
+	},
+	{
+		name:    "copy",
+		args:    "event-id...",
+		help:    "copy events to another calendar, or to another time",
+		flags:   copyFlags,
+		run:     runCopy,
+		usesID:  true,
+		needsID: true,
+	},
+	{
+		name:    "update",
+		args:    "event-id",
+		help:    "change the summary, times, description or location of an event",
+		flags:   updateFlags,
+		run:     runUpdate,
+		usesID:  true,
+		needsID: true,
+	},
+	{
+		name:  "calendars",
+		help:  "list the calendars the user has access to",
+		flags: calendarsFlags,
+		run:   runCalendars,
+	},
+	{
+		name:    "subscribe",
+		help:    "add a calendar, such as a public holiday calendar, to the user's list",
+		flags:   subscribeFlags,
+		run:     runSubscribe,
+		usesID:  true,
+		needsID: true,
+	},
+	{
+		name:    "unsubscribe",
+		help:    "remove a calendar from the user's list",
+		flags:   unsubscribeFlags,
+		run:     runUnsubscribe,
+		usesID:  true,
+		needsID: true,
+	},
+	{
+		name:  "overview",
+		help:  "summarize each calendar: this week's events, the next one, and whether it changed",
+		flags: overviewFlags,
+		run:   runOverview,
+	},
+	{
+		name:    "vacation",
+		help:    "block out days with an out-of-office event that declines invitations",
+		flags:   vacationFlags,
+		run:     runVacation,
+		usesID:  true,
+		needsID: true,
+	},
+	{
+		name:    "poll",
+		help:    "schedule the winning slots of a Doodle poll, inviting its participants",
+		flags:   pollFlags,
+		run:     runPoll,
+		usesID:  true,
+		needsID: true,
+	},
+	{
+		name:    "load",
+		help:    "report meeting hours, back-to-back meetings and after-hours meetings",
+		flags:   loadFlags,
+		run:     runLoad,
+		usesID:  true,
+		needsID: true,
+	},
+	{
+		name:    "reconcile",
+		help:    "keep recurring events on a calendar matching a file of weekly commitments",
+		flags:   reconcileFlags,
+		run:     runReconcile,
+		usesID:  true,
+		needsID: true,
+	},
+	{
+		name:     "overlay",
+		help:     "show when the primary calendars of several accounts are busy, and where they conflict",
+		flags:    overlayFlags,
+		run:      runOverlay,
+		ownCreds: true,
+	},
+	{
+		name:    "auth",
+		help:    "get creds for a user, to pass to -creds",
+		flags:   authFlags,
+		run:     runAuth,
+		noCreds: true,
+	},
+}
+
+func init() {
+	commands = append(commands, completionCommands...)
+	for _, c := range commands {
+		fs := c.flags
+		if !c.noCreds {
+			fs.StringVar(&credsFile, "creds", "", "filename for creds")
+			fs.StringVar(&credsCmd, "creds-command", "", "shell command that prints creds JSON, used instead of -creds")
+			fs.BoolVar(&readOnly, "read-only", false, "fail any API request that could change something")
+			fs.BoolVar(&noCache, "no-cache", false, "don't cache API responses")
+			fs.BoolVar(&jsonOut, "json", false, "write JSON instead of text")
+		}
+		if c.usesID {
+			fs.Var(&calIDs, "id", "ID of calendar (typically, user email address)")
+		}
+		fs.BoolVar(&verbose, "v", false, "log more detail, including each API request")
+		fs.BoolVar(&quiet, "q", false, "log only errors")
+		fs.StringVar(&logFormat, "log-format", "", "format of log messages: text, or json (the default with -json)")
+		fs.StringVar(&cacheDirFlag, "cache-dir", "", "directory for cached state (default $CAL_CACHE_DIR, or cal in the user cache directory)")
+		fs.StringVar(&nowFlag, "now", "", "RFC 3339 time to use as the current time, for reproducible output")
+		fs.StringVar(&inputTZ, "timezone", "", "read times in events files and flags in this IANA time zone instead of the local one")
+		fs.StringVar(&displayTZ, "display-tz", "", "show times in this IANA time zone, e.g. Europe/Berlin")
+		fs.BoolVar(&noColor, "no-color", false, "don't color listings, even on a terminal (also $NO_COLOR)")
+		fs.Usage = func() {
+			synopsis := strings.TrimSpace("cal " + c.name + " [flags] " + c.args)
+			fmt.Fprintf(os.Stderr, "usage: %s\n\n%s\n\nFlags:\n", synopsis, c.help)
+			fs.PrintDefaults()
+		}
 	}
-	if *eventFile == "" {
-		log.Fatal("need -events")
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: cal command [flags]\n\nCommands:\n")
+	for _, c := range visibleCommands() {
+		fmt.Fprintf(os.Stderr, "  %-11s %s\n", c.name, c.help)
 	}
+	fmt.Fprintf(os.Stderr, "\nRun \"cal help command\" for more about a command.\n")
+	fmt.Fprintf(os.Stderr, "Any flag can also be set in the environment: -display-tz as CAL_DISPLAY_TZ, and so on.\n")
+}
 
-	hc, _, err := htrans.NewClient(ctx, option.WithCredentialsFile(*credsFile))
+func lookupCommand(name string) *command {
+	for _, c := range commands {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func main() {
+	args := os.Args[1:]
+	if len(args) == 0 {
+		usage()
+		os.Exit(2)
+	}
+	name := args[0]
+	switch {
+	case name == "help" || name == "-h" || name == "-help" || name == "--help":
+		if len(args) > 1 {
+			if c := lookupCommand(args[1]); c != nil {
+				c.flags.Usage()
+				return
+			}
+		}
+		usage()
+		return
+	case strings.HasPrefix(name, "-"):
+		// Before there were commands, cal only inserted events.
+		name = "insert"
+	default:
+		args = args[1:]
+	}
+	c := lookupCommand(name)
+	if c == nil {
+		fmt.Fprintf(os.Stderr, "cal: unknown command %q\n", name)
+		usage()
+		os.Exit(2)
+	}
+	settings, err := readConfig(configFile())
 	if err != nil {
 		log.Fatal(err)
 	}
-	client, err := api.New(hc)
-	if err != nil {
+	if err := setFlagsFromConfig(c.name, c.flags, settings); err != nil {
 		log.Fatal(err)
 	}
-	evs, err := readEventFile(*eventFile)
-	if err != nil {
+	calIDs.defaulted = true
+	if err := setFlagsFromEnv(c.flags); err != nil {
+		log.Fatal(err)
+	}
+	calIDs.defaulted = true
+	c.flags.Parse(args)
+	if err := setupLogging(); err != nil {
 		log.Fatal(err)
 	}
-	start := *startIndex - 1
-	end := *endIndex - 1
-	if end < 0 || end >= len(evs) {
-		end = len(evs) - 1
+	if len(calIDs.ids) > 1 && !c.multiID {
+		log.Fatalf("cal %s takes only one -id", c.name)
 	}
-	fmt.Printf("start=%d, end=%d\n", start, end)
-	if !*doit {
-		fmt.Println("provide -doit to insert")
-		return
+	aliases = configSection(settings, "aliases")
+	accounts = configSection(settings, "accounts")
+	budgets, err = parseBudgets(configSection(settings, "budgets"))
+	if err != nil {
+		log.Fatalf("%s: %v", configFile(), err)
+	}
+	if err := setTheme(configSection(settings, "colors")); err != nil {
+		log.Fatalf("%s: %v", configFile(), err)
+	}
+	// The default creds are needed unless every calendar names an account.
+	needCreds := len(calIDs.ids) == 0
+	for _, id := range calIDs.ids {
+		r, err := parseCalendarRef(id)
+		if err != nil {
+			log.Fatalf("-id: %v", err)
+		}
+		calRefs = append(calRefs, r)
+		needCreds = needCreds || r.account == ""
+	}
+	if len(calRefs) > 0 {
+		calID, idAccount = calRefs[0].id, calRefs[0].account
+	}
+	if c.needsID && calID == "" {
+		log.Fatal("need -id")
+	}
+	if !c.noCreds && !c.ownCreds && needCreds && credsFile == "" && credsCmd == "" {
+		log.Fatal("need -creds or -creds-command")
 	}
-	n := 0
-	for i := start; i <= end; i++ {
-		ev := evs[i]
-		err := insertEvent(ctx, client, *id, ev)
+	if nowFlag != "" {
+		t, err := time.Parse(time.RFC3339, nowFlag)
 		if err != nil {
+			log.Fatalf("-now: %v", err)
+		}
+		fixedNow = t
+	}
+	if inputTZ != "" {
+		if err := setInputZone(inputTZ); err != nil {
+			log.Fatalf("-timezone: %v", err)
+		}
+	}
+	if displayTZ != "" {
+		loc, err := time.LoadLocation(displayTZ)
+		if err != nil {
+			log.Fatalf("-display-tz: %v", err)
+		}
+		displayLoc = loc
+	}
+	args = c.flags.Args()
+	if takesEventIDs(c) && cacheDir() != "" {
+		// Short prefixes of the IDs listed last stand for the full IDs.
+		var err error
+		if args, err = resolveEventIDs(args); err != nil {
 			log.Fatal(err)
 		}
-		fmt.Printf("inserted %s - %s\t%q\t%s\n", ev.Start.DateTime, ev.End.DateTime, ev.Summary, ev.Description)
-		n++
 	}
-	fmt.Printf("inserted %d events.\n", n)
+	c.run(context.Background(), args)
 }
 
-// File format: blank-line-separated events, each of which is:
-//		Friday January 19
-//		7:00pm – 9:00pm
-//      summary
-//		optional description line 1
-//		optional description line 2
-//		...
-func readEventFile(filename string) ([]*api.Event, error) {
-	bytes, err := ioutil.ReadFile(filename)
-	if err != nil {
-		return nil, err
+// An idList is the value of -id: one or more calendar IDs, from repeated
+// flags or a comma-separated list.
+type idList struct {
+	ids []string
+	// The IDs so far came from the config file or the environment, so
+	// the first -id on the command line replaces them.
+	defaulted bool
+}
+
+func (l *idList) String() string {
+	return strings.Join(l.ids, ",")
+}
+
+func (l *idList) Set(s string) error {
+	if l.defaulted {
+		l.ids, l.defaulted = nil, false
 	}
-	var evs []*api.Event
-	for _, sev := range strings.Split(string(bytes), "\n\n") {
-		e, err := parseEvent(sev)
-		if err != nil {
-			return nil, err
+	for _, id := range strings.Split(s, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			l.ids = append(l.ids, id)
 		}
-		evs = append(evs, e)
 	}
-	return evs, nil
+	return nil
 }
 
-func parseEvent(e string) (*api.Event, error) {
-	lines := strings.Split(e, "\n")
-	// Trim whitespace, replace en-dash with hyphen.
-	for i := range lines {
-		lines[i] = strings.Replace(strings.TrimSpace(lines[i]),
-			"–", "-", -1)
+// setFlagsFromEnv sets each flag in fs from its environment variable, if
+// that is set. The variable for -display-tz is CAL_DISPLAY_TZ, and so on;
+// see also envAliases. Call it before fs.Parse, so that command-line
+// flags take precedence.
+func setFlagsFromEnv(fs *flag.FlagSet) error {
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil {
+			return
+		}
+		for _, name := range envNames(f.Name) {
+			if v, ok := os.LookupEnv(name); ok {
+				if e := fs.Set(f.Name, v); e != nil {
+					err = fmt.Errorf("$%s: %v", name, e)
+				}
+				return
+			}
+		}
+	})
+	return err
+}
+
+// envAliases holds shorter environment variables for some flags. They are
+// used only if the flag's own variable is unset.
+var envAliases = map[string]string{
+	"display-tz": "CAL_TZ",
+}
+
+// envNames returns the environment variables for the flag, in order of
+// precedence.
+func envNames(flagName string) []string {
+	names := []string{envName(flagName)}
+	if a, ok := envAliases[flagName]; ok {
+		names = append(names, a)
 	}
-	if len(lines) < 3 {
-		return nil, fmt.Errorf("too few lines: %q", e)
+	return names
+}
+
+func envName(flagName string) string {
+	return "CAL_" + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+var (
+	credsMu sync.Mutex
+	creds   = map[string]credsResult{} // by account
+)
+
+type credsResult struct {
+	data []byte
+	err  error
+}
+
+// credsJSON returns the creds for the account of the -id calendar.
+func credsJSON() ([]byte, error) {
+	return credsFor(idAccount)
+}
+
+// credsFor returns the creds of account: for the empty account, the
+// contents of the -creds file or the output of -creds-command, and for
+// others, the contents of their file in the config. Each is read at most
+// once, and kept only in memory.
+func credsFor(account string) ([]byte, error) {
+	credsMu.Lock()
+	defer credsMu.Unlock()
+	if r, ok := creds[account]; ok {
+		return r.data, r.err
 	}
-	date := lines[0]
-	times := strings.Split(lines[1], "-")
-	if len(times) != 2 {
-		return nil, fmt.Errorf("bad time line: %q\n", lines[1])
+	var r credsResult
+	switch {
+	case account != "":
+		r.data, r.err = ioutil.ReadFile(accounts[account])
+	case credsCmd == "":
+		r.data, r.err = ioutil.ReadFile(credsFile)
+	default:
+		cmd := exec.Command("sh", "-c", credsCmd)
+		cmd.Stderr = os.Stderr
+		r.data, r.err = cmd.Output()
+		if r.err != nil {
+			r.err = fmt.Errorf("-creds-command: %v", r.err)
+		}
 	}
-	summary := lines[2]
-	desc := strings.Join(lines[3:], "\n")
+	creds[account] = r
+	return r.data, r.err
+}
 
-	start, err := parseTime(date + " " + times[0])
-	if err != nil {
-		return nil, err
-	}
-	end, err := parseTime(date + " " + times[1])
-	if err != nil {
-		return nil, err
+// newHTTPClient returns an HTTP client authorized by the creds of the
+// -id calendar's account.
+func newHTTPClient(ctx context.Context) *http.Client {
+	return newHTTPClientFor(ctx, idAccount)
+}
+
+// newHTTPClientFor returns an HTTP client authorized by the creds of
+// account. Nothing is done with the creds until the first request, so a
+// command that turns out not to need the network doesn't pay for reading
+// them, running -creds-command, or setting up OAuth.
+func newHTTPClientFor(ctx context.Context, account string) *http.Client {
+	return guard(&http.Client{Transport: &lazyTransport{ctx: ctx, account: account}})
+}
+
+// lazyTransport builds the authorized transport on its first use.
+type lazyTransport struct {
+	ctx     context.Context
+	account string
+	once    sync.Once
+	rt      http.RoundTripper
+	err     error
+}
+
+func (t *lazyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.once.Do(func() {
+		data, err := credsFor(t.account)
+		if err != nil {
+			t.err = err
+			return
+		}
+		hc, err := calendar.NewHTTPClient(t.ctx, calendar.WithCredentialsJSON(data), calendar.WithScopes(scopes()...))
+		if err != nil {
+			t.err = err
+			return
+		}
+		hc = cached(hc, data)
+		t.rt = hc.Transport
+		if t.rt == nil {
+			t.rt = http.DefaultTransport
+		}
+		t.rt = logged(t.rt)
+	})
+	if t.err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, t.err
 	}
-	return &api.Event{
-		Start:       &api.EventDateTime{DateTime: start.Format(time.RFC3339)},
-		End:         &api.EventDateTime{DateTime: end.Format(time.RFC3339)},
-		Summary:     summary,
-		Description: desc,
-	}, nil
+	return t.rt.RoundTrip(req)
 }
 
-// e.g. "2018 January 17 5:30pm"
-func parseTime(s string) (time.Time, error) {
-	s = strings.TrimSpace(s)
-	// First try without minutes.
-	t, err := time.ParseInLocation("2006 January 2 3pm", s, time.Local)
-	if err == nil {
-		return t, nil
+// cached returns hc, changed to cache GET responses unless -no-cache is set.
+func cached(hc *http.Client, creds []byte) *http.Client {
+	if noCache {
+		return hc
 	}
-	return time.ParseInLocation("2006 January 2 3:04pm", s, time.Local)
+	base := hc.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	t := newCachingTransport(base, creds)
+	if t == nil {
+		return hc
+	}
+	c := *hc
+	c.Transport = t
+	return &c
 }
 
-func insertEvent(ctx context.Context, c *api.Service, calID string, ev *api.Event) error {
-	_, err := c.Events.Insert(calID, ev).Context(ctx).Do()
-	return err
+// newClient returns a calendar client authorized by the creds of the -id
+// calendar's account, with the CLI's middleware.
+func newClient(ctx context.Context) *calendar.Client {
+	return newClientFor(ctx, idAccount)
 }
 
-func listEvents(ctx context.Context, c *api.Service, calID string) {
-	call := c.Events.List(calID).Context(ctx)
-	call.SingleEvents(true)
-	call.OrderBy("startTime")
-	tm := time.Now().Format(time.RFC3339)
-	fmt.Println(tm)
-	call.TimeMin(tm)
-	events, err := call.Do()
+// newClientFor is like newClient, for the calendars of account.
+func newClientFor(ctx context.Context, account string) *calendar.Client {
+	client, err := calendar.NewClient(ctx,
+		calendar.WithHTTPClient(newHTTPClientFor(ctx, account)),
+		calendar.WithMiddleware(logCalls))
 	if err != nil {
 		log.Fatal(err)
 	}
-	for i, e := range events.Items {
-		fmt.Printf("%d: Start:%s End:%s  Summary:%s\n",
-			i, eventTime(e.Start), eventTime(e.End), e.Summary)
-	}
+	return client
 }
 
-func eventTime(dt *api.EventDateTime) string {
-	if dt.Date != "" {
-		return dt.Date
-	}
-	return dt.DateTime
+// newService returns a Calendar API service authorized by the creds.
+func newService(ctx context.Context) *api.Service {
+	return newClient(ctx).Service()
 }
 
-// List all calendars that the authenticated user has access to.
-func listCalendars(c *api.Service) {
-	clist, err := c.CalendarList.List().Do()
-	if err != nil {
-		log.Fatal(err)
+var (
+	authFlags    = flag.NewFlagSet("auth", flag.ExitOnError)
+	clientID     = authFlags.String("client-id", "", "OAuth client ID of your project")
+	clientSecret = authFlags.String("client-secret", "", "OAuth client secret of your project")
+	authDrive    = authFlags.Bool("drive", false, "also grant access to the Google Docs that insert -long-desc-doc creates")
+	authGmail    = authFlags.Bool("gmail", false, "also grant sending the mail of insert -email-report")
+)
+
+func runAuth(ctx context.Context, args []string) {
+	cfg := *ocfg
+	if *clientID != "" {
+		cfg.ClientID = *clientID
 	}
-	for i, e := range clist.Items {
-		fmt.Printf("%d: ID:%q Primary:%t Summary:%q\n",
-			i, e.Id, e.Primary, e.Summary)
+	if *clientSecret != "" {
+		cfg.ClientSecret = *clientSecret
 	}
+	if *authDrive {
+		cfg.Scopes = append(cfg.Scopes, drive.DriveFileScope)
+	}
+	if *authGmail {
+		cfg.Scopes = append(cfg.Scopes, gmail.GmailSendScope)
+	}
+	getUserConsentManual(&cfg)
 }
 
 var ocfg = &oauth2.Config{
@@ -190,10 +618,21 @@ var ocfg = &oauth2.Config{
 	Scopes:       []string{api.CalendarScope},
 }
 
+// scopes returns the scopes to ask for on behalf of the command:
+// Calendar, and Drive only if the command puts descriptions in Docs.
+func scopes() []string {
+	if *longDescDoc {
+		return []string{api.CalendarScope, drive.DriveFileScope}
+	}
+	return []string{api.CalendarScope}
+}
+
 // Call this once to get creds. The resulting JSON should be stored
 // in a protected file whose name should be passed to -creds.
+// Scopes the user granted before are kept, so running it again with
+// more scopes adds them to the same creds.
 func getUserConsentManual(cfg *oauth2.Config) {
-	url := ocfg.AuthCodeURL("xyzzy", oauth2.AccessTypeOffline)
+	url := cfg.AuthCodeURL("xyzzy", oauth2.AccessTypeOffline, oauth2.SetAuthURLParam("include_granted_scopes", "true"))
 	fmt.Println("have the user visit this url:")
 	fmt.Println(url)
 	fmt.Println("Take the resulting auth code and paste it here, then hit return:")
@@ -201,7 +640,7 @@ func getUserConsentManual(cfg *oauth2.Config) {
 	fmt.Scanf("%s", &code)
 	fmt.Printf("code = %q\n", code)
 
-	tok, err := ocfg.Exchange(context.Background(), code)
+	tok, err := cfg.Exchange(context.Background(), code)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -212,5 +651,6 @@ func getUserConsentManual(cfg *oauth2.Config) {
     "client_id": %q,
     "client_secret": %q,
     "refresh_token": %q
-}\n`, ocfg.ClientID, ocfg.ClientSecret, tok.RefreshToken)
+}
+`, cfg.ClientID, cfg.ClientSecret, tok.RefreshToken)
 }
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2/google"
+)
+
+// splitObjectURL splits a URL like gs://bucket/path/to/object into its
+// bucket and object names.
+func splitObjectURL(u string) (bucket, object string, err error) {
+	_, rest, _ := strings.Cut(u, "://")
+	bucket, object, _ = strings.Cut(rest, "/")
+	if bucket == "" || object == "" {
+		return "", "", fmt.Errorf("%s: want a URL like gs://bucket/object", u)
+	}
+	return bucket, object, nil
+}
+
+// readGCS reads an object from Google Cloud Storage, using Application
+// Default Credentials.
+func readGCS(u string) ([]byte, error) {
+	bucket, object, err := splitObjectURL(u)
+	if err != nil {
+		return nil, err
+	}
+	hc, err := google.DefaultClient(context.Background(), "https://www.googleapis.com/auth/devstorage.read_only")
+	if err != nil {
+		return nil, err
+	}
+	res, err := hc.Get("https://storage.googleapis.com/storage/v1/b/" + url.PathEscape(bucket) +
+		"/o/" + url.PathEscape(object) + "?alt=media")
+	if err != nil {
+		return nil, err
+	}
+	return readResponse(u, res)
+}
+
+// readS3 reads an object from Amazon S3. If AWS_ACCESS_KEY_ID and
+// AWS_SECRET_ACCESS_KEY are set, the request is signed with them;
+// otherwise the object must be public. Those are the only credentials
+// used: not shared config files or profiles, and not instance or task
+// roles. The region comes from AWS_REGION or AWS_DEFAULT_REGION, and
+// defaults to us-east-1.
+func readS3(u string) ([]byte, error) {
+	bucket, object, err := splitObjectURL(u)
+	if err != nil {
+		return nil, err
+	}
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	host := bucket + ".s3." + region + ".amazonaws.com"
+	path := "/" + s3Escape(object)
+	req, err := http.NewRequest("GET", "https://"+host+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	key, secret := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if (key == "") != (secret == "") {
+		return nil, fmt.Errorf("%s: need both AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY, or neither for a public object", u)
+	}
+	if key != "" {
+		signS3(req, host, path, region, key, secret, os.Getenv("AWS_SESSION_TOKEN"), time.Now())
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if key == "" && (res.StatusCode == http.StatusForbidden || res.StatusCode == http.StatusUnauthorized) {
+		res.Body.Close()
+		return nil, fmt.Errorf("%s: %s: the object isn't public, and AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY aren't set (cal reads no other AWS credentials)", u, res.Status)
+	}
+	return readResponse(u, res)
+}
+
+func readResponse(u string, res *http.Response) ([]byte, error) {
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", u, res.Status)
+	}
+	return ioutil.ReadAll(res.Body)
+}
+
+// signS3 adds AWS Signature Version 4 headers to an S3 GET request.
+func signS3(req *http.Request, host, path, region, keyID, secret, token string, now time.Time) {
+	const emptyHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	headers := map[string]string{
+		"host":                 host,
+		"x-amz-content-sha256": emptyHash,
+		"x-amz-date":           amzDate,
+	}
+	if token != "" {
+		headers["x-amz-security-token"] = token
+	}
+	var names []string
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canon strings.Builder
+	for _, k := range names {
+		canon.WriteString(k + ":" + headers[k] + "\n")
+		if k != "host" {
+			req.Header.Set(k, headers[k])
+		}
+	}
+	signed := strings.Join(names, ";")
+	creq := strings.Join([]string{"GET", path, "", canon.String(), signed, emptyHash}, "\n")
+	scope := date + "/" + region + "/s3/aws4_request"
+	sts := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex(creq)
+	k := hmacSHA256([]byte("AWS4"+secret), date)
+	k = hmacSHA256(k, region)
+	k = hmacSHA256(k, "s3")
+	k = hmacSHA256(k, "aws4_request")
+	sig := hex.EncodeToString(hmacSHA256(k, sts))
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+keyID+"/"+scope+
+		", SignedHeaders="+signed+", Signature="+sig)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func sha256Hex(s string) string {
+	h := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(h[:])
+}
+
+// s3Escape escapes an object key for a URL path as S3 expects:
+// everything but unreserved characters and slashes.
+func s3Escape(key string) string {
+	var b strings.Builder
+	for _, c := range []byte(key) {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
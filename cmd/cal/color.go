@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	api "google.golang.org/api/calendar/v3"
+)
+
+// noColor, from -no-color, turns off colored output. So does a NO_COLOR
+// environment variable that is set and not empty, as at no-color.org.
+var noColor bool
+
+// theme holds the SGR codes that color each kind of line in a listing:
+//
+//	today     events that start today
+//	allday    all-day events
+//	conflict  events that overlap an event listed before them
+//
+// The [colors] section of the config file can change them, as in
+// today = "1;33" for bold yellow.
+var theme = map[string]string{
+	"today":    "1",
+	"allday":   "2",
+	"conflict": "31",
+}
+
+// setTheme changes theme by the settings of the [colors] section.
+func setTheme(section map[string]string) error {
+	for k, v := range section {
+		if _, ok := theme[k]; !ok {
+			return fmt.Errorf("[colors]: unknown color %q; colors are today, allday, conflict", k)
+		}
+		if strings.Trim(v, "0123456789;") != "" {
+			return fmt.Errorf("[colors]: %s: want SGR codes like \"1;33\", got %q", k, v)
+		}
+		theme[k] = v
+	}
+	return nil
+}
+
+// useColor reports whether to color the text written to standard output.
+func useColor() bool {
+	if noColor || os.Getenv("NO_COLOR") != "" || jsonOut {
+		return false
+	}
+	fi, err := os.Stdout.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// A colorizer colors the lines of a listing of events in order of start
+// time, by theme.
+type colorizer struct {
+	today   string    // date of now, in the display zone
+	lastEnd time.Time // the latest end of the timed events so far
+}
+
+func newColorizer() *colorizer {
+	return &colorizer{today: now().In(displayZone()).Format(time.DateOnly)}
+}
+
+// paint returns line, the listing of e, colored.
+func (c *colorizer) paint(e *api.Event, line string) string {
+	var codes []string
+	add := func(kind string) {
+		if theme[kind] != "" {
+			codes = append(codes, theme[kind])
+		}
+	}
+	allDay := e.Start != nil && e.Start.Date != ""
+	if allDay {
+		add("allday")
+	}
+	start, err := eventStart(e)
+	switch {
+	case allDay && e.Start.Date == c.today,
+		!allDay && err == nil && start.In(displayZone()).Format(time.DateOnly) == c.today:
+		add("today")
+	}
+	if !allDay && err == nil && e.End != nil {
+		if start.Before(c.lastEnd) {
+			add("conflict")
+		}
+		if end, err := parseDateTime(e.End); err == nil && end.After(c.lastEnd) {
+			c.lastEnd = end
+		}
+	}
+	if len(codes) == 0 {
+		return line
+	}
+	return "\x1b[" + strings.Join(codes, ";") + "m" + line + "\x1b[0m"
+}
+
+// displayZone returns the zone in which times are shown.
+func displayZone() *time.Location {
+	if displayLoc != nil {
+		return displayLoc
+	}
+	return inputLoc
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	api "google.golang.org/api/calendar/v3"
+)
+
+// These commands refer to commands, so they can't be in its initializer;
+// init adds them.
+var completionCommands = []*command{
+	{
+		name:    "completion",
+		args:    "bash|zsh|fish",
+		help:    "print a shell completion script",
+		flags:   flag.NewFlagSet("completion", flag.ExitOnError),
+		run:     runCompletion,
+		noCreds: true,
+	},
+	{
+		// Called by the completion scripts. It only reads the cache, so
+		// completing never waits on the network.
+		name:    "__complete",
+		args:    "calendars|events",
+		flags:   flag.NewFlagSet("__complete", flag.ExitOnError),
+		run:     runComplete,
+		noCreds: true,
+		hidden:  true,
+	},
+}
+
+func runCompletion(ctx context.Context, args []string) {
+	if len(args) != 1 {
+		log.Fatal("need one of bash, zsh, fish")
+	}
+	var err error
+	switch args[0] {
+	case "bash":
+		err = writeBashCompletion(os.Stdout)
+	case "zsh":
+		// zsh can run bash completion functions.
+		fmt.Println("autoload -U +X bashcompinit && bashcompinit")
+		err = writeBashCompletion(os.Stdout)
+	case "fish":
+		err = writeFishCompletion(os.Stdout)
+	default:
+		log.Fatalf("unknown shell %q; choices are bash, zsh, fish", args[0])
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func runComplete(ctx context.Context, args []string) {
+	if len(args) != 1 {
+		os.Exit(2)
+	}
+	if args[0] == "calendars" {
+		// Aliases complete too.
+		if settings, err := readConfig(configFile()); err == nil {
+			var names []string
+			for a := range configSection(settings, "aliases") {
+				names = append(names, a)
+			}
+			sort.Strings(names)
+			for _, a := range names {
+				fmt.Println(a)
+			}
+		}
+	}
+	data, err := os.ReadFile(completionFile(args[0]))
+	if err != nil {
+		// Nothing cached yet.
+		return
+	}
+	os.Stdout.Write(data)
+}
+
+// completionFile returns the file holding the cached completions of the
+// given kind.
+func completionFile(kind string) string {
+	return filepath.Join(cacheDir(), "complete", kind)
+}
+
+// saveCompletions caches lines, for completing words of the given kind:
+// "calendars" from cal calendars, and "events" from cal search and cal
+// list. Failure only affects completion, so it is logged and otherwise
+// ignored.
+func saveCompletions(kind string, lines []string) {
+	if cacheDir() == "" {
+		return
+	}
+	err := writeCacheFile(completionFile(kind), []byte(strings.Join(lines, "\n")+"\n"))
+	if err != nil {
+		slog.Warn(fmt.Sprintf("saving completions: %v", err))
+	}
+}
+
+// completionLine returns the line cached for completing e's ID: the ID,
+// start and quoted summary, separated by tabs.
+func completionLine(e *api.Event) string {
+	return fmt.Sprintf("%s\t%s\t%q", e.Id, eventTime(e.Start), e.Summary)
+}
+
+// resolveEventIDs returns ids with each that is a prefix of just one of
+// the cached event IDs replaced by that ID. IDs that are not prefixes of
+// any are left alone, so full IDs work whatever the cache holds; a prefix
+// of several is an error.
+func resolveEventIDs(ids []string) ([]string, error) {
+	data, err := os.ReadFile(completionFile("events"))
+	if err != nil {
+		// Nothing cached yet.
+		return ids, nil
+	}
+	var cached []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if id, _, _ := strings.Cut(line, "\t"); id != "" {
+			cached = append(cached, id)
+		}
+	}
+	var res []string
+	for _, id := range ids {
+		var matches []string
+		for _, c := range cached {
+			if c == id {
+				matches = []string{c}
+				break
+			}
+			if strings.HasPrefix(c, id) && !slices.Contains(matches, c) {
+				matches = append(matches, c)
+			}
+		}
+		switch len(matches) {
+		case 0:
+			res = append(res, id)
+		case 1:
+			res = append(res, matches[0])
+		default:
+			return nil, fmt.Errorf("event ID %q is ambiguous: it could be %s", id, strings.Join(matches, ", "))
+		}
+	}
+	return res, nil
+}
+
+// takesEventIDs reports whether c's arguments are event IDs.
+func takesEventIDs(c *command) bool {
+	return strings.HasPrefix(c.args, "event-id")
+}
+
+func visibleCommands() []*command {
+	var cs []*command
+	for _, c := range commands {
+		if !c.hidden {
+			cs = append(cs, c)
+		}
+	}
+	return cs
+}
+
+func flagNames(fs *flag.FlagSet) []string {
+	var names []string
+	fs.VisitAll(func(f *flag.Flag) { names = append(names, "-"+f.Name) })
+	sort.Strings(names)
+	return names
+}
+
+func writeBashCompletion(w io.Writer) error {
+	var b strings.Builder
+	var names, evCmds []string
+	for _, c := range visibleCommands() {
+		names = append(names, c.name)
+		if takesEventIDs(c) {
+			evCmds = append(evCmds, c.name)
+		}
+	}
+	fmt.Fprintf(&b, `_cal() {
+	local cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]}
+	if [ "$COMP_CWORD" -eq 1 ]; then
+		COMPREPLY=($(compgen -W %q -- "$cur"))
+		return
+	fi
+	case $prev in
+	-id|-calendar)
+		COMPREPLY=($(compgen -W "$(cal __complete calendars 2>/dev/null)" -- "$cur"))
+		return;;
+	esac
+	case $cur in
+	-*)
+		case ${COMP_WORDS[1]} in
+`, strings.Join(names, " "))
+	for _, c := range visibleCommands() {
+		fmt.Fprintf(&b, "\t\t%s) COMPREPLY=($(compgen -W %q -- \"$cur\"));;\n", c.name, strings.Join(flagNames(c.flags), " "))
+	}
+	fmt.Fprintf(&b, `		esac
+		return;;
+	esac
+	case ${COMP_WORDS[1]} in
+	%s)
+		COMPREPLY=($(compgen -W "$(cal __complete events 2>/dev/null | cut -f1)" -- "$cur"));;
+	esac
+}
+complete -o default -F _cal cal
+`, strings.Join(evCmds, "|"))
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeFishCompletion(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("complete -c cal -f\n")
+	for _, c := range visibleCommands() {
+		fmt.Fprintf(&b, "complete -c cal -n __fish_use_subcommand -a %s -d %s\n", c.name, fishQuote(c.help))
+	}
+	for _, c := range visibleCommands() {
+		cond := "'__fish_seen_subcommand_from " + c.name + "'"
+		c.flags.VisitAll(func(f *flag.Flag) {
+			extra := ""
+			if f.Name == "id" || f.Name == "calendar" {
+				extra = " -x -a '(cal __complete calendars 2>/dev/null)'"
+			}
+			fmt.Fprintf(&b, "complete -c cal -n %s -o %s -d %s%s\n", cond, f.Name, fishQuote(f.Usage), extra)
+		})
+		if takesEventIDs(c) {
+			fmt.Fprintf(&b, "complete -c cal -n %s -a '(cal __complete events 2>/dev/null)'\n", cond)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
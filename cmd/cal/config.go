@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// The config file holds defaults for flags, in a small subset of TOML:
+//
+//	# Settings before any section apply to every command that has the flag.
+//	creds = "~/keys/me.json"
+//	id = "me@example.com"
+//	display-tz = "Europe/Berlin"
+//
+//	# Settings in a section apply only to that command.
+//	[list]
+//	max = 20
+//
+//	# Names to use for calendars in -id.
+//	[aliases]
+//	team = "abc123@group.calendar.google.com"
+//
+//	# Creds files of other Google accounts, for calendar references like
+//	# work:primary.
+//	[accounts]
+//	work = "~/keys/work.json"
+//
+//	# Limits on the time events can take; see budget.
+//	[budgets]
+//	tomato = "10h/week"
+//
+//	# Colors of listings on a terminal; see theme.
+//	[colors]
+//	today = "1;33"
+//
+// Keys are flag names. Values are quoted strings, or bare numbers and
+// booleans. A leading ~/ in a string is replaced by the home directory.
+// The environment and the command line override the config file.
+
+// configFile returns the name of the config file: $CAL_CONFIG, or
+// cal/config.toml in the user config directory.
+func configFile() string {
+	if f := os.Getenv("CAL_CONFIG"); f != "" {
+		return f
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "cal", "config.toml")
+}
+
+// A configSetting is one key = value line of the config file.
+type configSetting struct {
+	section, key, value string
+	line                int
+}
+
+// readConfig reads the config file. A missing file is not an error.
+func readConfig(filename string) ([]configSetting, error) {
+	if filename == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) && os.Getenv("CAL_CONFIG") == "" {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	settings, err := parseConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%v", filename, err)
+	}
+	return settings, nil
+}
+
+func parseConfig(data []byte) ([]configSetting, error) {
+	var settings []configSetting
+	section := ""
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			name, ok := strings.CutSuffix(line, "]")
+			if !ok {
+				return nil, fmt.Errorf("%d: bad section %q", n, line)
+			}
+			section = strings.TrimSpace(name[1:])
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%d: want key = value, got %q", n, line)
+		}
+		key = strings.TrimSpace(key)
+		value, err := configValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%d: %s: %v", n, key, err)
+		}
+		settings = append(settings, configSetting{section, key, value, n})
+	}
+	return settings, s.Err()
+}
+
+// configValue returns the value of v, a TOML string, number or boolean,
+// possibly followed by a comment.
+func configValue(v string) (string, error) {
+	if strings.HasPrefix(v, `"`) {
+		// Find the closing quote, skipping escaped ones.
+		end := 1
+		for end < len(v) && v[end] != '"' {
+			if v[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(v) {
+			return "", fmt.Errorf("unterminated string %s", v)
+		}
+		if rest := strings.TrimSpace(v[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+			return "", fmt.Errorf("unexpected %q after string", rest)
+		}
+		s, err := strconv.Unquote(v[:end+1])
+		if err != nil {
+			return "", err
+		}
+		if rest, ok := strings.CutPrefix(s, "~/"); ok {
+			if home, err := os.UserHomeDir(); err == nil {
+				s = filepath.Join(home, rest)
+			}
+		}
+		return s, nil
+	}
+	if i := strings.Index(v, "#"); i >= 0 {
+		v = strings.TrimSpace(v[:i])
+	}
+	if v == "" {
+		return "", fmt.Errorf("missing value")
+	}
+	return v, nil
+}
+
+// setFlagsFromConfig sets the flags of the command named cmd from
+// settings. Call it before setFlagsFromEnv and fs.Parse, so that they
+// take precedence. Settings in the command's section are applied after
+// those before any section, so that they take precedence too.
+func setFlagsFromConfig(cmd string, fs *flag.FlagSet, settings []configSetting) error {
+	for _, s := range settings {
+		if s.section == "aliases" || s.section == "accounts" || s.section == "budgets" || s.section == "colors" {
+			continue
+		}
+		if s.section != "" && lookupCommand(s.section) == nil {
+			return fmt.Errorf("%s:%d: unknown command %q", configFile(), s.line, s.section)
+		}
+	}
+	for _, section := range []string{"", cmd} {
+		for _, s := range settings {
+			if s.section != section {
+				continue
+			}
+			if fs.Lookup(s.key) == nil {
+				if s.section == "" && anyCommandHasFlag(s.key) {
+					// A default for other commands.
+					continue
+				}
+				if s.section == "" {
+					return fmt.Errorf("%s:%d: no command has a flag -%s", configFile(), s.line, s.key)
+				}
+				return fmt.Errorf("%s:%d: cal %s has no flag -%s", configFile(), s.line, s.section, s.key)
+			}
+			if err := fs.Set(s.key, s.value); err != nil {
+				return fmt.Errorf("%s:%d: %s: %v", configFile(), s.line, s.key, err)
+			}
+		}
+		// An -id in the section replaces the global one.
+		calIDs.defaulted = true
+	}
+	return nil
+}
+
+func anyCommandHasFlag(name string) bool {
+	for _, c := range commands {
+		if c.flags.Lookup(name) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	// aliases maps the names in the [aliases] section of the config file
+	// to calendar IDs.
+	aliases map[string]string
+
+	// accounts maps the names in the [accounts] section of the config file
+	// to creds files.
+	accounts map[string]string
+)
+
+// resolveCalendar returns the calendar ID that id is an alias for, or id.
+func resolveCalendar(id string) string {
+	if a, ok := aliases[id]; ok {
+		return a
+	}
+	return id
+}
+
+// A calRef is a calendar of a particular account. The account is empty
+// for the one given by -creds or -creds-command.
+type calRef struct {
+	account, id string
+}
+
+// parseCalendarRef parses a calendar given as an ID or alias, optionally
+// qualified by an account from the config file, as in work:primary. An
+// alias can stand for a qualified reference.
+func parseCalendarRef(s string) (calRef, error) {
+	s = resolveCalendar(s)
+	if acct, id, ok := strings.Cut(s, ":"); ok && !strings.Contains(acct, "@") {
+		if _, ok := accounts[acct]; !ok {
+			return calRef{}, fmt.Errorf("%q: no account %q in %s", s, acct, configFile())
+		}
+		return calRef{acct, resolveCalendar(id)}, nil
+	}
+	return calRef{"", s}, nil
+}
+
+func (r calRef) String() string {
+	if r.account == "" {
+		return r.id
+	}
+	return r.account + ":" + r.id
+}
+
+// configSection returns the settings in section as a map.
+func configSection(settings []configSetting, section string) map[string]string {
+	m := map[string]string{}
+	for _, s := range settings {
+		if s.section == section {
+			m[s.key] = s.value
+		}
+	}
+	return m
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	api "google.golang.org/api/calendar/v3"
+)
+
+// errNoTerminal explains what to do instead of -i when there is no one to
+// ask, as in a cron job or a container.
+var errNoTerminal = errors.New("-i needs a terminal to ask on; to run without prompts, use -doit to insert every event, or neither flag to only show them")
+
+// openPrompt returns the terminal that the questions of -i are answered
+// on: standard input, or /dev/tty when the events themselves come from
+// standard input. It fails if that is not a terminal, so that -i fails
+// before any work is done rather than when it first asks.
+func openPrompt(fromStdin bool) (*os.File, error) {
+	in := os.Stdin
+	if fromStdin {
+		tty, err := os.Open("/dev/tty")
+		if err != nil {
+			return nil, errNoTerminal
+		}
+		in = tty
+	}
+	if !isTerminal(in) {
+		return nil, errNoTerminal
+	}
+	return in, nil
+}
+
+// isTerminal reports whether f looks like a terminal: a character device
+// other than /dev/null, which is what containers usually give for
+// standard input.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	null, err := os.Stat(os.DevNull)
+	return err != nil || !os.SameFile(fi, null)
+}
+
+// confirmEvents shows each of evs on standard error and asks whether to
+// insert it, reading the answers from in: y for yes, n to skip it, a for
+// it and all the rest, q to skip it and all the rest. It returns the
+// events chosen.
+func confirmEvents(evs []*api.Event, in io.Reader) ([]*api.Event, error) {
+	r := bufio.NewReader(in)
+	var chosen []*api.Event
+	for i, ev := range evs {
+		fmt.Fprintf(os.Stderr, "\n--- event %d of %d\n", i+1, len(evs))
+		printEvent(os.Stderr, ev)
+		answer, err := ask(r)
+		if err != nil {
+			return nil, err
+		}
+		switch answer {
+		case "y":
+			chosen = append(chosen, ev)
+		case "a":
+			return append(chosen, evs[i:]...), nil
+		case "q":
+			return chosen, nil
+		}
+	}
+	return chosen, nil
+}
+
+// ask prompts for y, n, a or q until it gets one. End of input counts
+// as q.
+func ask(r *bufio.Reader) (string, error) {
+	for {
+		fmt.Fprint(os.Stderr, "insert? [y,n,a,q] ")
+		line, err := r.ReadString('\n')
+		if err == io.EOF && line == "" {
+			fmt.Fprintln(os.Stderr)
+			return "q", nil
+		}
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		switch a := strings.ToLower(strings.TrimSpace(line)); a {
+		case "y", "n", "a", "q":
+			return a, nil
+		}
+		fmt.Fprintln(os.Stderr, "y: insert this event; n: skip it; a: insert it and all the rest; q: skip it and all the rest")
+	}
+}
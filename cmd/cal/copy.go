@@ -0,0 +1,311 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	api "google.golang.org/api/calendar/v3"
+)
+
+var (
+	copyFlags  = flag.NewFlagSet("copy", flag.ExitOnError)
+	copyTo     = copyFlags.String("to", "", "ID or alias of the calendar to copy the events to (default the -id calendar)")
+	copyShift  = copyFlags.String("shift", "", "move the copies by this much, like 7d, -1d or 90m; days keep the time of day across DST changes")
+	copyDoit   = copyFlags.Bool("doit", false, "nothing is copied unless this is provided")
+	copyStdin  = copyFlags.Bool("stdin", false, "also read event IDs from standard input, one per line")
+	copySeries = copyFlags.Bool("series", false, "for an instance of a recurring event, copy the whole series, once")
+)
+
+func runCopy(ctx context.Context, args []string) {
+	dest := calRef{idAccount, calID}
+	if *copyTo != "" {
+		var err error
+		if dest, err = parseCalendarRef(*copyTo); err != nil {
+			log.Fatalf("-to: %v", err)
+		}
+	}
+	var sh shift
+	if *copyShift != "" {
+		var err error
+		if sh, err = parseShift(*copyShift); err != nil {
+			log.Fatalf("-shift: %v", err)
+		}
+	}
+	if dest == calRefs[0] && sh == (shift{}) {
+		log.Fatal("need -to another calendar, or -shift")
+	}
+	ids := args
+	if *copyStdin {
+		in, err := readIDs(os.Stdin)
+		if err != nil {
+			log.Fatal(err)
+		}
+		ids = append(ids, in...)
+	}
+	if len(ids) == 0 {
+		log.Fatal("no event IDs")
+	}
+	client := newClient(ctx)
+	destClient := client
+	if dest.account != idAccount {
+		destClient = newClientFor(ctx, dest.account)
+	}
+	failed, ncopied := 0, 0
+	fail := func(eid string, err error) {
+		failed++
+		if jsonOut {
+			emitJSON(eventRecord{Action: "failed", Calendar: calID, ID: eid, Error: err.Error()})
+			return
+		}
+		log.Printf("%s: %v", eid, err)
+	}
+	copied := map[string]bool{} // series, with -series
+	for _, eid := range ids {
+		old, err := client.Service().Events.Get(calID, eid).Context(ctx).Do()
+		if err == nil && *copySeries && old.RecurringEventId != "" {
+			eid = old.RecurringEventId
+			if copied[eid] {
+				continue
+			}
+			copied[eid] = true
+			old, err = client.Service().Events.Get(calID, eid).Context(ctx).Do()
+		}
+		if err != nil {
+			fail(eid, err)
+			continue
+		}
+		ev, err := copyEvent(old, sh)
+		if err != nil {
+			fail(eid, err)
+			continue
+		}
+		if !*copyDoit {
+			if jsonOut {
+				r := newEventRecord("would copy", ev)
+				r.Calendar = dest.String()
+				emitJSON(r)
+				continue
+			}
+			fmt.Printf("would copy %s\t%s\t%q\n", eid, eventTime(ev.Start), ev.Summary)
+			continue
+		}
+		ev, err = destClient.InsertEvent(ctx, dest.id, ev)
+		if err != nil {
+			fail(eid, err)
+			continue
+		}
+		ncopied++
+		if jsonOut {
+			r := newEventRecord("copied", ev)
+			r.Calendar = dest.String()
+			emitJSON(r)
+			continue
+		}
+		fmt.Printf("copied %s to %s\t%s\t%q\n", eid, ev.Id, eventTime(ev.Start), ev.Summary)
+	}
+	if !*copyDoit {
+		info("provide -doit to copy to %s\n", dest)
+	} else {
+		info("copied %d events to %s.\n", ncopied, dest)
+	}
+	switch {
+	case failed == len(ids):
+		os.Exit(1)
+	case failed > 0:
+		os.Exit(exitPartialFailure)
+	}
+}
+
+// A shift is how far cal copy moves events.
+type shift struct {
+	days int
+	d    time.Duration
+}
+
+// parseShift parses a number of days like 7d or -1d, or a duration.
+func parseShift(s string) (shift, error) {
+	if n, ok := strings.CutSuffix(s, "d"); ok {
+		if days, err := strconv.Atoi(n); err == nil {
+			return shift{days: days}, nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return shift{d: d}, nil
+	}
+	return shift{}, fmt.Errorf("bad shift %q: want days like 7d, or a duration like 90m", s)
+}
+
+// copyEvent returns a new event with the contents of ev, moved by sh.
+// What the server assigns, like the ID, is left out, as are instance
+// details: copying one instance of a recurring event makes a single
+// event.
+func copyEvent(ev *api.Event, sh shift) (*api.Event, error) {
+	c := &api.Event{
+		Summary:                 ev.Summary,
+		Description:             ev.Description,
+		Location:                ev.Location,
+		ColorId:                 ev.ColorId,
+		Transparency:            ev.Transparency,
+		Visibility:              ev.Visibility,
+		PrivateCopy:             ev.PrivateCopy,
+		Attendees:               ev.Attendees,
+		Reminders:               ev.Reminders,
+		ExtendedProperties:      ev.ExtendedProperties,
+		GuestsCanInviteOthers:   ev.GuestsCanInviteOthers,
+		GuestsCanModify:         ev.GuestsCanModify,
+		GuestsCanSeeOtherGuests: ev.GuestsCanSeeOtherGuests,
+		Source:                  ev.Source,
+	}
+	if ev.Status != "cancelled" {
+		// So that tentative holds stay tentative.
+		c.Status = ev.Status
+	}
+	var err error
+	if c.Start, err = shiftDateTime(ev.Start, sh); err != nil {
+		return nil, fmt.Errorf("start: %v", err)
+	}
+	if c.End, err = shiftDateTime(ev.End, sh); err != nil {
+		return nil, fmt.Errorf("end: %v", err)
+	}
+	days, err := dateShift(ev.Start, c.Start)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range ev.Recurrence {
+		if sh == (shift{}) {
+			c.Recurrence = append(c.Recurrence, line)
+			continue
+		}
+		if !strings.HasPrefix(line, "RRULE:") {
+			return nil, fmt.Errorf("can't shift recurrence line %q", line)
+		}
+		r, err := shiftRule(strings.TrimPrefix(line, "RRULE:"), days, sh)
+		if err != nil {
+			return nil, err
+		}
+		c.Recurrence = append(c.Recurrence, "RRULE:"+r)
+	}
+	return c, nil
+}
+
+// dateShift returns the number of days between the dates of from and to,
+// in their own time zones.
+func dateShift(from, to *api.EventDateTime) (int, error) {
+	date := func(dt *api.EventDateTime) (time.Time, error) {
+		if dt.Date != "" {
+			return time.Parse(time.DateOnly, dt.Date)
+		}
+		t, err := parseDateTime(dt)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if dt.TimeZone != "" {
+			if loc, err := time.LoadLocation(dt.TimeZone); err == nil {
+				t = t.In(loc)
+			}
+		} else {
+			t = t.In(inputLoc)
+		}
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+	}
+	f, err := date(from)
+	if err != nil {
+		return 0, err
+	}
+	t, err := date(to)
+	if err != nil {
+		return 0, err
+	}
+	return int(t.Sub(f).Hours() / 24), nil
+}
+
+// shiftRule returns the RRULE value rule for a series whose start moved
+// by sh, onto a date days later. UNTIL moves with the series, and BYDAY
+// weekdays move with its dates. Other parts are kept as they are, unless
+// they name days of the month or year that the shift would make wrong.
+func shiftRule(rule string, days int, sh shift) (string, error) {
+	parts := strings.Split(rule, ";")
+	for i, part := range parts {
+		k, v, ok := strings.Cut(part, "=")
+		if !ok {
+			return "", fmt.Errorf("recurrence rule %q: bad part %q", rule, part)
+		}
+		switch k = strings.ToUpper(k); k {
+		case "UNTIL":
+			layout := "20060102T150405Z"
+			if len(v) == len("20060102") {
+				layout = "20060102"
+			}
+			u, err := time.Parse(layout, v)
+			if err != nil {
+				return "", fmt.Errorf("recurrence rule %q: UNTIL: %v", rule, err)
+			}
+			if layout == "20060102" {
+				u = u.AddDate(0, 0, days)
+			} else {
+				u = u.AddDate(0, 0, sh.days).Add(sh.d)
+			}
+			parts[i] = k + "=" + u.Format(layout)
+		case "BYDAY":
+			if days%7 == 0 {
+				continue
+			}
+			var wds []string
+			for _, d := range strings.Split(strings.ToUpper(v), ",") {
+				wd, ok := parseWeekday(d)
+				if !ok || len(d) != 2 {
+					// An ordinal like 1MO: the first Monday and the day
+					// after the first Tuesday are different.
+					return "", fmt.Errorf("recurrence rule %q: can't shift %s by %d days", rule, d, days)
+				}
+				wd = time.Weekday(((int(wd)+days)%7 + 7) % 7)
+				wds = append(wds, strings.ToUpper(wd.String()[:2]))
+			}
+			parts[i] = k + "=" + strings.Join(wds, ",")
+		case "BYMONTHDAY", "BYYEARDAY", "BYWEEKNO", "BYMONTH", "BYSETPOS":
+			if days != 0 {
+				return "", fmt.Errorf("recurrence rule %q: can't shift %s by %d days", rule, k, days)
+			}
+		}
+	}
+	return strings.Join(parts, ";"), nil
+}
+
+// shiftDateTime returns a copy of dt moved by sh. Days are added in the
+// event's time zone, or in inputLoc, so the time of day stays the same.
+// All-day dates can only be moved by days.
+func shiftDateTime(dt *api.EventDateTime, sh shift) (*api.EventDateTime, error) {
+	if dt == nil {
+		return nil, nil
+	}
+	c := *dt
+	if dt.Date != "" {
+		if sh.d != 0 {
+			return nil, fmt.Errorf("all-day event can only be shifted by days")
+		}
+		t, err := time.Parse(time.DateOnly, dt.Date)
+		if err != nil {
+			return nil, err
+		}
+		c.Date = t.AddDate(0, 0, sh.days).Format(time.DateOnly)
+		return &c, nil
+	}
+	t, err := parseDateTime(dt)
+	if err != nil {
+		return nil, err
+	}
+	loc := inputLoc
+	if dt.TimeZone != "" {
+		if loc, err = time.LoadLocation(dt.TimeZone); err != nil {
+			return nil, err
+		}
+	}
+	c.DateTime = t.In(loc).AddDate(0, 0, sh.days).Add(sh.d).Format(time.RFC3339)
+	return &c, nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	api "google.golang.org/api/calendar/v3"
+)
+
+// csvFields are the fields that -map can assign to columns.
+var csvFields = []string{"date", "start", "end", "summary", "desc", "location", "color"}
+
+// readCSV parses events from CSV, one per row, with the columns given by
+// -map. The start column holds a time, parsed as by the time line of the
+// text format together with the date column, if any; without a date
+// column, it must hold the date too. The end column is optional, as is
+// the end time of a time line.
+func readCSV(data []byte) ([]*api.Event, error) {
+	m, err := parseCSVMap(*csvMap)
+	if err != nil {
+		return nil, fmt.Errorf("-map: %v", err)
+	}
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	// Column names need a header row to find them in.
+	header := *csvHeader
+	for _, c := range m {
+		if _, err := strconv.Atoi(c); err != nil {
+			header = true
+		}
+	}
+	first := 0
+	if header {
+		if len(rows) == 0 {
+			return nil, fmt.Errorf("no header row")
+		}
+		first = 1
+	}
+	cols := map[string]int{}
+	for f, c := range m {
+		if n, err := strconv.Atoi(c); err == nil {
+			cols[f] = n - 1
+			continue
+		}
+		i := indexFold(rows[0], c)
+		if i < 0 {
+			return nil, fmt.Errorf("-map: no column %q in header %q", c, rows[0])
+		}
+		cols[f] = i
+	}
+	var evs []*api.Event
+	for i, row := range rows[first:] {
+		get := func(field string) string {
+			if c, ok := cols[field]; ok && c < len(row) {
+				return strings.TrimSpace(row[c])
+			}
+			return ""
+		}
+		if strings.Join(row, "") == "" {
+			continue
+		}
+		ev, err := fieldsEvent(get)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %v", first+i+1, err)
+		}
+		evs = append(evs, ev)
+	}
+	return evs, nil
+}
+
+// fieldsEvent makes an event from the fields returned by get, named as in
+// csvFields. It is shared by the formats that name their fields.
+func fieldsEvent(get func(string) string) (*api.Event, error) {
+	summary := get("summary")
+	desc := get("desc")
+	if *sanitizeDesc {
+		var err error
+		if desc, err = sanitizeHTML(desc); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateText(summary, desc); err != nil {
+		return nil, err
+	}
+	date := get("date")
+	if date != "" {
+		date += " "
+	}
+	if get("start") == "" {
+		return nil, fmt.Errorf("%q: no start time", summary)
+	}
+	start, err := parseTime(date + get("start"))
+	if err != nil {
+		return nil, err
+	}
+	end := start.Add(*defaultDuration)
+	if e := get("end"); e != "" {
+		end, err = parseTime(date + e)
+		if err != nil {
+			return nil, err
+		}
+		if !end.After(start) {
+			return nil, fmt.Errorf("%q: end time %s is not after start time %s", summary, get("end"), get("start"))
+		}
+	}
+	ev := &api.Event{
+		Summary:     summary,
+		Description: desc,
+		Location:    get("location"),
+		Start:       eventDateTime(start),
+		End:         eventDateTime(end),
+	}
+	if c := get("color"); c != "" {
+		if err := setColor(ev, c); err != nil {
+			return nil, fmt.Errorf("%q: %v", summary, err)
+		}
+	}
+	return ev, nil
+}
+
+// parseCSVMap parses a -map value like "date=1,start=2,summary=Title"
+// into a map from field to column number or header name.
+func parseCSVMap(s string) (map[string]string, error) {
+	m := map[string]string{}
+	for _, part := range strings.Split(s, ",") {
+		f, c, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || c == "" {
+			return nil, fmt.Errorf("bad part %q: want field=column", part)
+		}
+		if !slices.Contains(csvFields, f) {
+			return nil, fmt.Errorf("unknown field %q; fields are %s", f, strings.Join(csvFields, ", "))
+		}
+		if n, err := strconv.Atoi(c); err == nil && n < 1 {
+			return nil, fmt.Errorf("%s=%d: columns are numbered from 1", f, n)
+		}
+		m[f] = c
+	}
+	if m["start"] == "" || m["summary"] == "" {
+		return nil, fmt.Errorf("need columns for start and summary")
+	}
+	return m, nil
+}
+
+// indexFold returns the index of the first of ss equal to s, ignoring
+// case, or -1.
+func indexFold(ss []string, s string) int {
+	for i, x := range ss {
+		if strings.EqualFold(strings.TrimSpace(x), s) {
+			return i
+		}
+	}
+	return -1
+}
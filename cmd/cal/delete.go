@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+var (
+	deleteFlags = flag.NewFlagSet("delete", flag.ExitOnError)
+	deleteDoit  = deleteFlags.Bool("doit", false, "nothing is deleted unless this is provided")
+	deleteStdin = deleteFlags.Bool("stdin", false, "also read event IDs from standard input, one per line")
+)
+
+func runDelete(ctx context.Context, args []string) {
+	ids := args
+	if *deleteStdin {
+		in, err := readIDs(os.Stdin)
+		if err != nil {
+			log.Fatal(err)
+		}
+		ids = append(ids, in...)
+	}
+	if len(ids) == 0 {
+		log.Fatal("no event IDs")
+	}
+	client := newClient(ctx)
+	failed := 0
+	fail := func(eid string, err error) {
+		failed++
+		if jsonOut {
+			emitJSON(eventRecord{Action: "failed", ID: eid, Error: err.Error()})
+			return
+		}
+		log.Printf("%s: %v", eid, err)
+	}
+	for _, eid := range ids {
+		if !*deleteDoit {
+			ev, err := client.Service().Events.Get(calID, eid).Context(ctx).Do()
+			if err != nil {
+				fail(eid, err)
+				continue
+			}
+			if jsonOut {
+				emitJSON(newEventRecord("would delete", ev))
+				continue
+			}
+			fmt.Printf("would delete %s\t%s\t%q\n", eid, eventTime(ev.Start), ev.Summary)
+			continue
+		}
+		if err := client.DeleteEvent(ctx, calID, eid); err != nil {
+			fail(eid, err)
+			continue
+		}
+		if jsonOut {
+			emitJSON(eventRecord{Action: "deleted", ID: eid})
+			continue
+		}
+		fmt.Printf("deleted %s\n", eid)
+	}
+	if !*deleteDoit {
+		info("provide -doit to delete\n")
+	} else {
+		info("deleted %d events.\n", len(ids)-failed)
+	}
+	switch {
+	case failed == len(ids):
+		os.Exit(1)
+	case failed > 0:
+		os.Exit(exitPartialFailure)
+	}
+}
+
+// readIDs reads event IDs, one per line. Only the first field of each
+// line is used, so lines can carry other information after the ID.
+func readIDs(r io.Reader) ([]string, error) {
+	var ids []string
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		if fields := strings.Fields(s.Text()); len(fields) > 0 {
+			ids = append(ids, fields[0])
+		}
+	}
+	return ids, s.Err()
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	api "google.golang.org/api/calendar/v3"
+)
+
+// Setters for the fields that can be given in the text format by lines
+// like "status: tentative" among the description lines. Lines whose
+// key isn't listed here are part of the description.
+var fieldSetters = map[string]func(ev *api.Event, val string) error{
+	"status": setStatus,
+	"url":    setSource,
+	"color":  setColor,
+}
+
+// setField sets a field of ev if line is a field line.
+// It reports whether it was.
+func setField(ev *api.Event, line string) (bool, error) {
+	k, v, ok := strings.Cut(line, ":")
+	if !ok {
+		return false, nil
+	}
+	set := fieldSetters[strings.ToLower(strings.TrimSpace(k))]
+	if set == nil {
+		return false, nil
+	}
+	return true, set(ev, strings.TrimSpace(v))
+}
+
+// formatFields returns the field lines for ev, the inverse of setField.
+func formatFields(ev *api.Event) []string {
+	var lines []string
+	if ev.Status != "" {
+		lines = append(lines, "status: "+ev.Status)
+	}
+	if ev.Source != nil {
+		lines = append(lines, "url: "+ev.Source.Url+" "+ev.Source.Title)
+	}
+	if ev.ColorId != "" {
+		lines = append(lines, "color: "+colorName(ev.ColorId))
+	}
+	return lines
+}
+
+func setStatus(ev *api.Event, val string) error {
+	switch s := strings.ToLower(val); s {
+	case "confirmed", "tentative":
+		ev.Status = s
+		return nil
+	default:
+		return fmt.Errorf("bad status %q; want confirmed or tentative", val)
+	}
+}
+
+// setColor sets the event's color from a name of eventColors, like
+// tomato, or a color ID.
+func setColor(ev *api.Event, val string) error {
+	if id, ok := eventColors[strings.ToLower(val)]; ok {
+		ev.ColorId = id
+		return nil
+	}
+	for _, id := range eventColors {
+		if val == id {
+			ev.ColorId = id
+			return nil
+		}
+	}
+	return fmt.Errorf("bad color %q; want a name like tomato or basil, or an ID from 1 to 11", val)
+}
+
+// colorName returns the name of the event color id, or id if it has none.
+func colorName(id string) string {
+	for name, i := range eventColors {
+		if i == id {
+			return name
+		}
+	}
+	return id
+}
+
+// setSource sets the event's source link from a value like
+// "https://example.com/schedule Spring schedule". The title is optional
+// and defaults to the URL's host.
+func setSource(ev *api.Event, val string) error {
+	u, title, _ := strings.Cut(val, " ")
+	pu, err := url.Parse(u)
+	if err != nil || (pu.Scheme != "http" && pu.Scheme != "https") || pu.Host == "" {
+		return fmt.Errorf("bad url %q; want an http or https URL", u)
+	}
+	title = strings.TrimSpace(title)
+	if title == "" {
+		title = pu.Host
+	}
+	ev.Source = &api.EventSource{Title: title, Url: u}
+	return nil
+}
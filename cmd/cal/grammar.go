@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// A grammar is a named set of layouts for the combined date and time
+// of an event, like "2018 January 17 5:30pm". Layouts are tried in
+// order.
+type grammar struct {
+	name    string
+	example string
+	layouts []string
+}
+
+var allGrammars = []grammar{
+	{"long", "2018 January 17 5:30pm", []string{"2006 January 2 3pm", "2006 January 2 3:04pm"}},
+	{"iso", "2018-01-17 17:30", []string{"2006-01-02 15:04", "2006-01-02 3pm", "2006-01-02 3:04pm"}},
+	{"us", "1/17/2018 5:30pm", []string{"1/2/2006 3pm", "1/2/2006 3:04pm", "1/2/2006 15:04"}},
+	{"eu", "17/1/2018 17:30", []string{"2/1/2006 15:04", "2.1.2006 15:04", "2/1/2006 3pm", "2/1/2006 3:04pm"}},
+}
+
+// The grammars that parseTime uses, in order.
+var timeGrammars []grammar
+
+// setGrammars sets timeGrammars from a comma-separated list of names.
+func setGrammars(names string) error {
+	var gs []grammar
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		g, ok := lookupGrammar(name)
+		if !ok {
+			var all []string
+			for _, g := range allGrammars {
+				all = append(all, g.name)
+			}
+			return fmt.Errorf("unknown grammar %q; choices are %s", name, strings.Join(all, ", "))
+		}
+		gs = append(gs, g)
+	}
+	timeGrammars = gs
+	return nil
+}
+
+func lookupGrammar(name string) (grammar, bool) {
+	for _, g := range allGrammars {
+		if g.name == name {
+			return g, true
+		}
+	}
+	return grammar{}, false
+}
+
+// parseTime parses s using the first grammar in timeGrammars that
+// matches, e.g. "2018 January 17 5:30pm". The time is in inputLoc.
+func parseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	colons := strings.Count(s, ":")
+	for _, g := range timeGrammars {
+		for _, layout := range g.layouts {
+			// Skip layouts that can't match, rather than paying for the
+			// error of a failed parse.
+			if strings.Count(layout, ":") != colons {
+				continue
+			}
+			if t, err := time.ParseInLocation(layout, s, inputLoc); err == nil {
+				return t, nil
+			}
+		}
+	}
+	var examples []string
+	for _, g := range timeGrammars {
+		examples = append(examples, fmt.Sprintf("%q", g.example))
+	}
+	return time.Time{}, fmt.Errorf("cannot parse %q as a time; expected something like %s", s, strings.Join(examples, " or "))
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/jba/calendar"
+	api "google.golang.org/api/calendar/v3"
+)
+
+// readICS parses the VEVENTs of an iCalendar file. Floating times are
+// read in inputLoc. Times given without a TZID are stored as -store-as
+// says; those with one are stored as wall times in that zone. Events are
+// checked and sanitized like those of the other formats.
+func readICS(data []byte) ([]*api.Event, error) {
+	ces, err := calendar.ReadICSIn(bytes.NewReader(data), inputLoc)
+	if err != nil {
+		return nil, err
+	}
+	if len(ces) == 0 {
+		return nil, fmt.Errorf("no VEVENTs found")
+	}
+	var evs []*api.Event
+	for i, ce := range ces {
+		if *sanitizeDesc {
+			var err error
+			if ce.Description, err = sanitizeHTML(ce.Description); err != nil {
+				return nil, fmt.Errorf("VEVENT %d: %v", i+1, err)
+			}
+		}
+		if err := validateText(ce.Summary, ce.Description); err != nil {
+			return nil, fmt.Errorf("VEVENT %d: %v", i+1, err)
+		}
+		ev := ce.ToAPI()
+		if !ce.AllDay && ce.TimeZone == "" {
+			ev.Start = eventDateTime(ce.Start)
+			ev.End = eventDateTime(ce.End)
+		}
+		evs = append(evs, ev)
+	}
+	return evs, nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// A weekly series as Google Calendar exports it, with one instance
+// deleted, one moved and one cancelled.
+const recurringICS = `BEGIN:VCALENDAR
+VERSION:2.0
+BEGIN:VEVENT
+UID:rehearsal@example.com
+DTSTART;TZID=America/New_York:20261005T190000
+DTEND;TZID=America/New_York:20261005T210000
+RRULE:FREQ=WEEKLY;WKST=SU;COUNT=8;BYDAY=MO
+EXDATE;TZID=America/New_York:20261012T190000
+SUMMARY:Rehearsal
+END:VEVENT
+BEGIN:VEVENT
+UID:rehearsal@example.com
+RECURRENCE-ID;TZID=America/New_York:20261019T190000
+DTSTART;TZID=America/New_York:20261020T180000
+DTEND;TZID=America/New_York:20261020T200000
+SUMMARY:Rehearsal (Tuesday this week)
+END:VEVENT
+BEGIN:VEVENT
+UID:rehearsal@example.com
+RECURRENCE-ID;TZID=America/New_York:20261026T190000
+DTSTART;TZID=America/New_York:20261026T190000
+DTEND;TZID=America/New_York:20261026T210000
+STATUS:CANCELLED
+SUMMARY:Rehearsal
+END:VEVENT
+END:VCALENDAR
+`
+
+func TestReadICSRecurrence(t *testing.T) {
+	evs, err := readICS([]byte(strings.ReplaceAll(recurringICS, "\n", "\r\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evs) != 2 {
+		t.Fatalf("got %d events, want the series and the moved instance", len(evs))
+	}
+	want := []string{
+		"RRULE:FREQ=WEEKLY;COUNT=8;BYDAY=MO",
+		"EXDATE;TZID=America/New_York:20261012T190000",
+		"EXDATE;TZID=America/New_York:20261019T190000",
+		"EXDATE;TZID=America/New_York:20261026T190000",
+	}
+	if got := evs[0].Recurrence; !slices.Equal(got, want) {
+		t.Errorf("series recurrence:\ngot  %q\nwant %q", got, want)
+	}
+	moved := evs[1]
+	if moved.ICalUID != "" || len(moved.Recurrence) != 0 {
+		t.Errorf("moved instance has UID %q and recurrence %q, want neither", moved.ICalUID, moved.Recurrence)
+	}
+	start, err := parseDateTime(moved.Start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 10, 20, 22, 0, 0, 0, time.UTC); !start.Equal(want) {
+		t.Errorf("moved instance starts at %s, want %s", start, want)
+	}
+}
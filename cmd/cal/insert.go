@@ -0,0 +1,409 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jba/calendar"
+	"golang.org/x/oauth2/google"
+	api "google.golang.org/api/calendar/v3"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
+)
+
+var (
+	insertFlags     = flag.NewFlagSet("insert", flag.ExitOnError)
+	eventFile       = insertFlags.String("events", "", "filename, - for stdin, or URL (http, https, gs, s3; s3 uses only $AWS_ACCESS_KEY_ID and $AWS_SECRET_ACCESS_KEY, not profiles or roles) of events (.json or .jsonl for JSON, .jsonld for schema.org JSON-LD, .yaml, .md, .ics for iCalendar, .rem for remind, .csv with -map)")
+	startIndex      = insertFlags.Int("start", 1, "1-based event to start inserting at")
+	endIndex        = insertFlags.Int("end", -1, "1-based event to end inserting at, inclusive")
+	doit            = insertFlags.Bool("doit", false, "nothing happens unless this is provided")
+	interactive     = insertFlags.Bool("i", false, "show each event and ask whether to insert it, instead of needing -doit")
+	retryFile       = insertFlags.String("retry", "", "if some inserts fail, write the failed events to this file, as JSON or iCalendar if it ends in .json, .jsonl or .ics")
+	sanitizeDesc    = insertFlags.Bool("sanitize-desc", false, "remove HTML that Calendar can't display from descriptions")
+	defaultDuration = insertFlags.Duration("default-duration", time.Hour, "length of events whose time line has only a start time")
+	storeAs         = insertFlags.String("store-as", "offset", "how to store times: offset (local time and UTC offset), wall (local time and zone name), or utc")
+	grammars        = insertFlags.String("grammars", "long,iso", "comma-separated date formats to try, in order: long, iso, us, eu")
+	longDescDoc     = insertFlags.Bool("long-desc-doc", false, "put descriptions that are too long for Calendar in a Google Doc, and link to it; the creds need the scope from auth -drive")
+	emailReport     = insertFlags.String("email-report", "", "email a report of the run to this address, using Gmail; the creds need the scope from auth -gmail")
+	csvMap          = insertFlags.String("map", "date=1,start=2,end=3,summary=4,desc=5", "for .csv events, the column of each field (date, start, end, summary, desc, location, color), by number from 1 or by header name")
+	csvHeader       = insertFlags.Bool("csv-header", false, "the first row of a .csv events file is a header; implied if -map names columns")
+	maxMeetingHours = insertFlags.Float64("max-meeting-hours", 0, "warn about weeks the events would push over this many meeting hours (0 for no check)")
+	targetsFile     = insertFlags.String("targets", "", "file of user emails, one per line; insert into each user's calendar using domain-wide delegation")
+)
+
+// Exit code when some, but not all, events were inserted.
+const exitPartialFailure = 2
+
+func runInsert(ctx context.Context, args []string) {
+	if calID == "" && *targetsFile == "" {
+		log.Fatal("need -id or -targets")
+	}
+	if *eventFile == "" {
+		log.Fatal("need -events")
+	}
+	if err := setGrammars(*grammars); err != nil {
+		log.Fatalf("-grammars: %v", err)
+	}
+	if err := setStoreMode(*storeAs); err != nil {
+		log.Fatalf("-store-as: %v", err)
+	}
+	if *retryFile != "" {
+		if err := checkWritable(*retryFile); err != nil {
+			log.Fatalf("-retry: %v", err)
+		}
+	}
+	var prompt *os.File
+	if *interactive {
+		var err error
+		if prompt, err = openPrompt(*eventFile == "-"); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	evs, err := readEventFile(*eventFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	start := *startIndex - 1
+	end := *endIndex - 1
+	if end < 0 || end >= len(evs) {
+		end = len(evs) - 1
+	}
+	if start < 0 || start >= len(evs) || start > end {
+		fmt.Fprintf(os.Stderr, "cal insert: -start %d and -end %d don't select any of the %d events in %s\n",
+			*startIndex, end+1, len(evs), *eventFile)
+		insertFlags.Usage()
+		os.Exit(2)
+	}
+	info("start=%d, end=%d\n", start, end)
+	var users []string
+	if *targetsFile != "" {
+		users, err = readTargets(*targetsFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		info("%d target users\n", len(users))
+	}
+	cals := users
+	if cals == nil {
+		for _, r := range calRefs {
+			cals = append(cals, r.String())
+		}
+	}
+	printImpact(evs[start:end+1], cals)
+	for _, r := range calRefs {
+		c := newClientFor(ctx, r.account).Service()
+		if *maxMeetingHours > 0 {
+			if err := warnMeetingLoad(ctx, c, r.id, evs[start:end+1], *maxMeetingHours); err != nil {
+				slog.Warn(fmt.Sprintf("checking meeting load of %s: %v", r, err))
+			}
+		}
+		if len(budgets) > 0 {
+			if err := warnBudgets(ctx, c, r.id, evs[start:end+1]); err != nil {
+				slog.Warn(fmt.Sprintf("checking budgets of %s: %v", r, err))
+			}
+		}
+	}
+	if *interactive {
+		evs, err = confirmEvents(evs[start:end+1], prompt)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(evs) == 0 {
+			info("nothing to insert\n")
+			return
+		}
+		start, end = 0, len(evs)-1
+	} else if !*doit {
+		info("provide -doit to insert, or -i to choose events\n")
+		return
+	}
+	results := map[string]*insertResult{}
+	if users == nil {
+		svcs := map[string]services{} // by account
+		for i, ref := range calRefs {
+			s, ok := svcs[ref.account]
+			if !ok {
+				s, err = newServices(ctx, newHTTPClientFor(ctx, ref.account))
+				if err != nil {
+					log.Fatal(err)
+				}
+				svcs[ref.account] = s
+			}
+			rf := *retryFile
+			if len(cals) > 1 {
+				info("--- %s\n", cals[i])
+				if rf != "" {
+					rf = retryName(rf, cals[i])
+				}
+			}
+			results[cals[i]] = insertEvents(ctx, s, ref.id, evs, start, end, rf)
+		}
+		if len(cals) > 1 {
+			printSummary("calendar", cals, results)
+		}
+	} else {
+		insertForUsers(ctx, users, evs, start, end, results)
+	}
+	if *emailReport != "" {
+		if err := sendReport(ctx, *emailReport, cals, results); err != nil {
+			slog.Warn(fmt.Sprintf("sending report: %v", err))
+		} else {
+			info("sent report to %s\n", *emailReport)
+		}
+	}
+	var total insertResult
+	for _, r := range results {
+		total.inserted = append(total.inserted, r.inserted...)
+		total.failed = append(total.failed, r.failed...)
+	}
+	os.Exit(total.exitCode())
+}
+
+func insertForUsers(ctx context.Context, users []string, evs []*api.Event, start, end int, results map[string]*insertResult) {
+	creds, err := credsJSON()
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, u := range users {
+		info("--- %s\n", u)
+		// Record a failure for this user and go on to the others, so the
+		// summary still gets printed.
+		hc, err := delegatedClient(ctx, creds, u)
+		var svcs services
+		if err == nil {
+			svcs, err = newServices(ctx, hc)
+		}
+		if err != nil {
+			log.Printf("%s: %v", u, err)
+			r := &insertResult{}
+			for _, ev := range evs[start : end+1] {
+				r.failed = append(r.failed, ev)
+				r.errs = append(r.errs, err)
+			}
+			results[u] = r
+			continue
+		}
+		rf := ""
+		if *retryFile != "" {
+			rf = retryName(*retryFile, u)
+		}
+		results[u] = insertEvents(ctx, svcs, u, evs, start, end, rf)
+	}
+	printSummary("user", users, results)
+}
+
+// printSummary prints how many events were inserted into and failed for
+// each of cals, which are labeled by kind.
+func printSummary(kind string, cals []string, results map[string]*insertResult) {
+	info("--- summary\n")
+	tw := tabwriter.NewWriter(infoWriter(), 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\tinserted\tfailed\n", kind)
+	for _, c := range cals {
+		r := results[c]
+		fmt.Fprintf(tw, "%s\t%d\t%d\n", c, len(r.inserted), len(r.failed))
+	}
+	tw.Flush()
+}
+
+// printImpact summarizes what inserting evs into cals would do.
+func printImpact(evs []*api.Event, cals []string) {
+	attendees := map[string]bool{}
+	for _, ev := range evs {
+		for _, a := range ev.Attendees {
+			attendees[a.Email] = true
+		}
+	}
+	info("impact:\n")
+	tw := tabwriter.NewWriter(infoWriter(), 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "  inserts\t%d\n", len(evs)*len(cals))
+	fmt.Fprintf(tw, "  updates\t0\n")
+	fmt.Fprintf(tw, "  deletes\t0\n")
+	if len(evs) > 0 {
+		fmt.Fprintf(tw, "  first event\t%s\n", eventTime(evs[0].Start))
+		fmt.Fprintf(tw, "  last event\t%s\n", eventTime(evs[len(evs)-1].Start))
+	}
+	fmt.Fprintf(tw, "  calendars\t%d\t%s\n", len(cals), strings.Join(cals, ", "))
+	fmt.Fprintf(tw, "  attendees\t%d\n", len(attendees))
+	fmt.Fprintf(tw, "  API calls\t%d\n", len(evs)*len(cals))
+	tw.Flush()
+}
+
+type insertResult struct {
+	inserted []*api.Event // as returned by the API
+	failed   []*api.Event
+	errs     []error // errs[i] is the error for failed[i]
+}
+
+func (r insertResult) exitCode() int {
+	switch {
+	case len(r.failed) == 0:
+		return 0
+	case len(r.inserted) == 0:
+		return 1
+	default:
+		return exitPartialFailure
+	}
+}
+
+// services holds the API clients used on behalf of one user.
+type services struct {
+	cal *calendar.Client
+}
+
+func newServices(ctx context.Context, hc *http.Client) (services, error) {
+	mw := []calendar.Middleware{logCalls}
+	if *longDescDoc {
+		docs, err := drive.NewService(ctx, option.WithHTTPClient(hc))
+		if err != nil {
+			return services{}, err
+		}
+		mw = append(mw, descriptionDocs(docs))
+	}
+	c, err := calendar.NewClient(ctx, calendar.WithHTTPClient(hc), calendar.WithMiddleware(mw...))
+	return services{cal: c}, err
+}
+
+// insertEvents inserts evs[start] through evs[end] into the calendar.
+// retryName returns the retry file for one of several calendars: name
+// with the calendar before its extension, so that it is read in the same
+// format.
+func retryName(name, cal string) string {
+	ext := filepath.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + cal + ext
+}
+
+// If retryFile is non-empty, failed events are written to it.
+func insertEvents(ctx context.Context, svcs services, calID string, evs []*api.Event, start, end int, retryFile string) *insertResult {
+	r := &insertResult{}
+	for i := start; i <= end; i++ {
+		ev := evs[i]
+		iev, err := insertEvent(ctx, svcs, calID, ev)
+		if err != nil {
+			if jsonOut {
+				rec := newEventRecord("failed", ev)
+				rec.Calendar, rec.Error = calID, err.Error()
+				emitJSON(rec)
+			} else {
+				log.Printf("event %d: %v", i+1, err)
+			}
+			r.failed = append(r.failed, ev)
+			r.errs = append(r.errs, err)
+			continue
+		}
+		if jsonOut {
+			rec := newEventRecord("inserted", iev)
+			rec.Calendar = calID
+			emitJSON(rec)
+		} else {
+			fmt.Printf("inserted %s - %s\t%s\t%q\t%s\n", eventTime(ev.Start), eventTime(ev.End), iev.Status, ev.Summary, ev.Description)
+		}
+		r.inserted = append(r.inserted, iev)
+	}
+	info("inserted %d events.\n", len(r.inserted))
+	if len(r.failed) == 0 {
+		return r
+	}
+	if !jsonOut {
+		fmt.Printf("%d events failed:\n", len(r.failed))
+		tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		for i, ev := range r.failed {
+			fmt.Fprintf(tw, "%s\t%q\t%v\n", eventTime(ev.Start), ev.Summary, r.errs[i])
+		}
+		tw.Flush()
+	}
+	if retryFile != "" {
+		if err := writeEventFile(retryFile, r.failed); err != nil {
+			log.Print(err)
+		} else {
+			info("wrote failed events to %s; retry with -events %[1]s\n", retryFile)
+		}
+	}
+	return r
+}
+
+// readTargets reads a file of email addresses, one per line.
+// Blank lines and lines beginning with '#' are ignored.
+func readTargets(filename string) ([]string, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var users []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		users = append(users, line)
+	}
+	if len(users) == 0 {
+		return nil, fmt.Errorf("%s: no users", filename)
+	}
+	return users, nil
+}
+
+// delegatedClient returns an HTTP client that acts as user, using
+// service-account credentials with domain-wide delegation.
+func delegatedClient(ctx context.Context, creds []byte, user string) (*http.Client, error) {
+	scopes := []string{api.CalendarScope}
+	if *longDescDoc {
+		scopes = append(scopes, drive.DriveFileScope)
+	}
+	cfg, err := google.JWTConfigFromJSON(creds, scopes...)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Subject = user
+	return guard(cfg.Client(ctx)), nil
+}
+
+func insertEvent(ctx context.Context, svcs services, calID string, ev *api.Event) (*api.Event, error) {
+	return svcs.cal.InsertEvent(ctx, calID, ev)
+}
+
+// descriptionDocs returns calendar middleware that moves descriptions too
+// long for Calendar into Google Docs; see moveDescriptionToDoc.
+func descriptionDocs(docs *drive.Service) calendar.Middleware {
+	return func(ctx context.Context, call *calendar.Call, next func(context.Context) error) error {
+		ev := call.Event
+		if call.Op != "insert" || utf8.RuneCountInString(ev.Description) <= maxDescriptionLen {
+			return next(ctx)
+		}
+		desc, err := moveDescriptionToDoc(ctx, docs, ev)
+		if err != nil {
+			return err
+		}
+		e := *ev
+		e.Description = desc
+		call.Event = &e
+		return next(ctx)
+	}
+}
+
+// moveDescriptionToDoc creates a Google Doc holding the full description
+// of ev, and returns a truncated description that links to it.
+func moveDescriptionToDoc(ctx context.Context, docs *drive.Service, ev *api.Event) (string, error) {
+	f, err := docs.Files.Create(&drive.File{
+		Name:     ev.Summary,
+		MimeType: "application/vnd.google-apps.document",
+	}).Media(strings.NewReader(ev.Description)).Fields("id", "webViewLink").Context(ctx).Do()
+	if err != nil {
+		return "", fmt.Errorf("creating doc for description: %v", err)
+	}
+	link := "\n\nFull description: " + f.WebViewLink
+	desc := []rune(ev.Description)
+	desc = desc[:maxDescriptionLen-utf8.RuneCountInString(link)-1]
+	return string(desc) + "…" + link, nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jba/calendar"
+	api "google.golang.org/api/calendar/v3"
+)
+
+// readJSONEvents parses events in the JSON form of calendar.Event: an
+// array of them or a single one, or, if lines is true, one per line.
+// Blank lines are ignored.
+func readJSONEvents(data []byte, lines bool) ([]*api.Event, error) {
+	var ces []*calendar.Event
+	if lines {
+		s := bufio.NewScanner(bytes.NewReader(data))
+		s.Buffer(nil, 1<<20)
+		for n := 1; s.Scan(); n++ {
+			line := bytes.TrimSpace(s.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			var ce calendar.Event
+			if err := json.Unmarshal(line, &ce); err != nil {
+				return nil, fmt.Errorf("line %d: %v", n, err)
+			}
+			ces = append(ces, &ce)
+		}
+		if err := s.Err(); err != nil {
+			return nil, err
+		}
+	} else {
+		data = bytes.TrimSpace(data)
+		if len(data) > 0 && data[0] == '{' {
+			data = append(append([]byte("["), data...), ']')
+		}
+		if err := json.Unmarshal(data, &ces); err != nil {
+			return nil, err
+		}
+	}
+	var evs []*api.Event
+	for i, ce := range ces {
+		if err := validateJSONEvent(ce); err != nil {
+			return nil, fmt.Errorf("event %d: %v", i+1, err)
+		}
+		ev := ce.ToAPI()
+		ev.Id = ""
+		if !ce.AllDay && ce.TimeZone == "" {
+			ev.Start = eventDateTime(ce.Start)
+			ev.End = eventDateTime(ce.End)
+		}
+		evs = append(evs, ev)
+	}
+	return evs, nil
+}
+
+// validateJSONEvent checks ce, sanitizing its description if
+// -sanitize-desc is set, and fills in a missing end.
+func validateJSONEvent(ce *calendar.Event) error {
+	if *sanitizeDesc {
+		var err error
+		if ce.Description, err = sanitizeHTML(ce.Description); err != nil {
+			return err
+		}
+	}
+	if err := validateText(ce.Summary, ce.Description); err != nil {
+		return err
+	}
+	if ce.Start.IsZero() {
+		return fmt.Errorf("%q: no start", ce.Summary)
+	}
+	if ce.End.IsZero() {
+		ce.End = ce.Start.Add(*defaultDuration)
+		if ce.AllDay {
+			ce.End = ce.Start.AddDate(0, 0, 1)
+		}
+	}
+	if !ce.End.After(ce.Start) {
+		return fmt.Errorf("%q: end is not after start", ce.Summary)
+	}
+	return nil
+}
+
+// isJSONLD reports whether data, a JSON file, holds schema.org JSON-LD
+// rather than calendar.Events: whether an object at its top level, or in
+// an array there, has an @context, @type or @graph.
+func isJSONLD(data []byte) bool {
+	var v any
+	if json.Unmarshal(data, &v) != nil {
+		// Let the JSON-LD reader report the error, as before.
+		return true
+	}
+	objs, ok := v.([]any)
+	if !ok {
+		objs = []any{v}
+	}
+	for _, o := range objs {
+		if m, ok := o.(map[string]any); ok {
+			for _, k := range []string{"@context", "@type", "@graph"} {
+				if _, ok := m[k]; ok {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
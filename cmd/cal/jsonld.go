@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	api "google.golang.org/api/calendar/v3"
+)
+
+// readJSONLD parses schema.org Event objects in JSON-LD form. The data
+// can be a single object, an array, or an object with an "@graph"
+// member. Objects whose @type isn't an Event are ignored.
+func readJSONLD(data []byte) ([]*api.Event, error) {
+	var objs []map[string]any
+	if err := collectJSONLD(data, &objs); err != nil {
+		return nil, err
+	}
+	var evs []*api.Event
+	for _, o := range objs {
+		if !isEventType(o["@type"]) {
+			continue
+		}
+		ev, err := jsonLDEvent(o)
+		if err != nil {
+			return nil, err
+		}
+		evs = append(evs, ev)
+	}
+	if len(evs) == 0 {
+		return nil, fmt.Errorf("no schema.org Events found")
+	}
+	return evs, nil
+}
+
+func collectJSONLD(data []byte, objs *[]map[string]any) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var arr []json.RawMessage
+		if err := json.Unmarshal(data, &arr); err != nil {
+			return err
+		}
+		for _, a := range arr {
+			if err := collectJSONLD(a, objs); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	var o map[string]any
+	if err := json.Unmarshal(data, &o); err != nil {
+		return err
+	}
+	if g, ok := o["@graph"]; ok {
+		b, err := json.Marshal(g)
+		if err != nil {
+			return err
+		}
+		return collectJSONLD(b, objs)
+	}
+	*objs = append(*objs, o)
+	return nil
+}
+
+// isEventType reports whether the @type value t is Event or one of its
+// subtypes, like MusicEvent or SportsEvent.
+func isEventType(t any) bool {
+	switch t := t.(type) {
+	case string:
+		return strings.HasSuffix(t, "Event")
+	case []any:
+		for _, e := range t {
+			if isEventType(e) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func jsonLDEvent(o map[string]any) (*api.Event, error) {
+	summary := jsonLDString(o["name"])
+	sd := jsonLDString(o["startDate"])
+	if sd == "" {
+		return nil, fmt.Errorf("%q: missing startDate", summary)
+	}
+	start, startAllDay, err := parseISODate(sd)
+	if err != nil {
+		return nil, fmt.Errorf("%q: %v", summary, err)
+	}
+	var end time.Time
+	endAllDay := startAllDay
+	if ed := jsonLDString(o["endDate"]); ed != "" {
+		end, endAllDay, err = parseISODate(ed)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %v", summary, err)
+		}
+	} else if startAllDay {
+		end = start
+	} else {
+		end = start.Add(*defaultDuration)
+	}
+	if startAllDay != endAllDay {
+		return nil, fmt.Errorf("%q: startDate and endDate must both be dates or both be times", summary)
+	}
+
+	desc := jsonLDString(o["description"])
+	if org := jsonLDName(o["organizer"]); org != "" {
+		if desc != "" {
+			desc += "\n"
+		}
+		desc += "Organizer: " + org
+	}
+	if *sanitizeDesc {
+		var err error
+		if desc, err = sanitizeHTML(desc); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateText(summary, desc); err != nil {
+		return nil, err
+	}
+	ev := &api.Event{
+		Summary:     summary,
+		Description: desc,
+		Location:    jsonLDLocation(o["location"]),
+	}
+	if u := jsonLDString(o["url"]); u != "" {
+		if err := setSource(ev, u); err != nil {
+			return nil, fmt.Errorf("%q: %v", summary, err)
+		}
+	}
+	if startAllDay {
+		// Calendar's end date is exclusive; schema.org's is inclusive.
+		if end.Before(start) {
+			return nil, fmt.Errorf("%q: endDate is before startDate", summary)
+		}
+		ev.Start = &api.EventDateTime{Date: start.Format(time.DateOnly)}
+		ev.End = &api.EventDateTime{Date: end.AddDate(0, 0, 1).Format(time.DateOnly)}
+	} else {
+		if !end.After(start) {
+			return nil, fmt.Errorf("%q: endDate is not after startDate", summary)
+		}
+		ev.Start = eventDateTime(start)
+		ev.End = eventDateTime(end)
+	}
+	return ev, nil
+}
+
+// parseISODate parses the ISO 8601 forms that appear in schema.org
+// data. Times without a zone offset are in inputLoc. The boolean
+// result is true if s is a date with no time.
+func parseISODate(s string) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation(time.DateOnly, s, inputLoc); err == nil {
+		return t, true, nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04Z07:00"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, false, nil
+		}
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04"} {
+		if t, err := time.ParseInLocation(layout, s, inputLoc); err == nil {
+			return t, false, nil
+		}
+	}
+	return time.Time{}, false, fmt.Errorf("bad date %q", s)
+}
+
+func jsonLDString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case []any:
+		if len(v) > 0 {
+			return jsonLDString(v[0])
+		}
+	}
+	return ""
+}
+
+// jsonLDName returns the name of a Person or Organization, or a
+// comma-separated list of names if v is an array.
+func jsonLDName(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case map[string]any:
+		name := jsonLDString(v["name"])
+		if email := jsonLDString(v["email"]); email != "" {
+			name = strings.TrimSpace(name + " <" + email + ">")
+		}
+		return name
+	case []any:
+		var names []string
+		for _, e := range v {
+			if n := jsonLDName(e); n != "" {
+				names = append(names, n)
+			}
+		}
+		return strings.Join(names, ", ")
+	}
+	return ""
+}
+
+// jsonLDLocation formats a Place (or a plain string) as a single line.
+func jsonLDLocation(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case map[string]any:
+		var parts []string
+		if name := jsonLDString(v["name"]); name != "" {
+			parts = append(parts, name)
+		}
+		switch a := v["address"].(type) {
+		case string:
+			parts = append(parts, a)
+		case map[string]any:
+			for _, k := range []string{"streetAddress", "addressLocality", "addressRegion", "postalCode", "addressCountry"} {
+				if s := jsonLDString(a[k]); s != "" {
+					parts = append(parts, s)
+				}
+			}
+		}
+		if len(parts) == 0 {
+			// A VirtualLocation has only a URL.
+			return jsonLDString(v["url"])
+		}
+		return strings.Join(parts, ", ")
+	case []any:
+		if len(v) > 0 {
+			return jsonLDLocation(v[0])
+		}
+	}
+	return ""
+}
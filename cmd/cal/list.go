@@ -0,0 +1,425 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	api "google.golang.org/api/calendar/v3"
+)
+
+var (
+	listFlags = flag.NewFlagSet("list", flag.ExitOnError)
+	listFrom  = listFlags.String("from", "now", "list events ending after this time")
+	listTo    = listFlags.String("to", "", "list events starting before this time")
+	listMax   = listFlags.Int("max", 0, "list at most this many events (0 for no limit)")
+	listPage  = listFlags.Int("page-size", 0, "events to fetch per request, at most 2500 (0 for the server's default)")
+	listPar   = listFlags.Int("parallel", 1, "with -to, split the time range into this many parts and fetch them concurrently")
+	listFmt   = listFlags.String("format", "", "Go template for each event's line, like '{{.Start}} {{.Summary}} ({{.Location}})'; see listedEvent for the fields")
+	listOut   = listFlags.String("o", "text", "output format: text, or tsv for lines of ID, start, end, status, calendar, summary and location separated by tabs")
+	listNul   = listFlags.Bool("print0", false, "end each event's line with a NUL byte instead of a newline, as for xargs -0")
+	listIDs   = listFlags.Bool("ids", false, "start each line with the event's ID")
+)
+
+func init() {
+	listFlags.Var(&calIDs, "calendar", "same as -id")
+}
+
+func runList(ctx context.Context, args []string) {
+	var q listQuery
+	var err error
+	q.from, err = parseListTime(*listFrom)
+	if err != nil {
+		log.Fatalf("-from: %v", err)
+	}
+	if *listTo != "" {
+		q.to, err = parseListTime(*listTo)
+		if err != nil {
+			log.Fatalf("-to: %v", err)
+		}
+	}
+	q.max = *listMax
+	if *listPage < 0 || *listPage > 2500 {
+		log.Fatal("-page-size must be between 0 and 2500")
+	}
+	q.pageSize = *listPage
+	q.parallel = *listPar
+	p, err := newListPrinter(*listFmt, *listOut, *listNul)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(calRefs) > 1 {
+		listMerged(ctx, calRefs, q, p)
+		return
+	}
+	listEvents(ctx, newService(ctx), calID, q, p)
+}
+
+var (
+	calendarsFlags = flag.NewFlagSet("calendars", flag.ExitOnError)
+)
+
+func runCalendars(ctx context.Context, args []string) {
+	listCalendars(ctx, newService(ctx))
+}
+
+// A listQuery restricts the events that listEvents lists.
+type listQuery struct {
+	from, to time.Time // zero for no limit
+	max      int       // 0 for no limit
+	pageSize int       // 0 for the server's default
+	parallel int       // parts to fetch concurrently; needs from and to
+}
+
+// parseListTime parses a time for -from or -to. It can be "now", an
+// RFC 3339 time, a date like 2018-01-17 (midnight in inputLoc), or an
+// offset from now like 36h, -2h or 7d.
+func parseListTime(s string) (time.Time, error) {
+	now := now()
+	if s == "now" {
+		return now, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, s, inputLoc); err == nil {
+		return t, nil
+	}
+	if n, ok := strings.CutSuffix(s, "d"); ok {
+		if days, err := strconv.Atoi(n); err == nil {
+			return now.AddDate(0, 0, days), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(d), nil
+	}
+	return time.Time{}, fmt.Errorf("bad time %q: want now, an RFC 3339 time, a date, or an offset like 7d", s)
+}
+
+// errEnough stops paging when enough events have been listed.
+var errEnough = errors.New("enough")
+
+// maxListCompletions is how many of the events it lists cal list saves
+// for completing and resolving event IDs, to keep a long listing from
+// holding all its events.
+const maxListCompletions = 1000
+
+// listLine returns the text line for e, the i'th event listed, of calendar
+// cal if the listing has several.
+func listLine(i int, e *api.Event, cal string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d: ", i)
+	if *listIDs {
+		fmt.Fprintf(&b, "ID:%s  ", e.Id)
+	}
+	fmt.Fprintf(&b, "Start:%s End:%s  Status:%s  ", eventTime(e.Start), eventTime(e.End), e.Status)
+	if cal != "" {
+		fmt.Fprintf(&b, "Calendar:%s  ", cal)
+	}
+	fmt.Fprintf(&b, "Summary:%s", e.Summary)
+	return b.String()
+}
+
+// listEvents prints the events of calID that match q. The first of them
+// are saved for completing event IDs.
+func listEvents(ctx context.Context, c *api.Service, calID string, q listQuery, p *listPrinter) {
+	i := 0
+	var found []string
+	emit := func(e *api.Event) error {
+		if q.max > 0 && i >= q.max {
+			return errEnough
+		}
+		if len(found) < maxListCompletions {
+			found = append(found, completionLine(e))
+		}
+		if jsonOut {
+			emitJSON(newEventRecord("", e))
+			i++
+			return nil
+		}
+		line := listLine(i, e, "")
+		i++
+		return p.print(e, "", line)
+	}
+	add, flush := sortTies(emit)
+	var err error
+	if q.parallel > 1 && !q.from.IsZero() && !q.to.IsZero() {
+		err = eachEventParallel(ctx, c, calID, q, add)
+	} else {
+		err = eachEvent(ctx, c, calID, q, add)
+	}
+	if err == nil {
+		err = flush()
+	}
+	if err != nil && err != errEnough {
+		log.Fatal(err)
+	}
+	saveCompletions("events", found)
+}
+
+// listMerged lists the events of several calendars, possibly of different
+// accounts, as one agenda. Each calendar's events are fetched and held in
+// memory, then printed together in order of start time.
+func listMerged(ctx context.Context, refs []calRef, q listQuery, p *listPrinter) {
+	type calEvent struct {
+		cal   string
+		start time.Time
+		ev    *api.Event
+	}
+	var all []calEvent
+	for _, r := range refs {
+		c := newClientFor(ctx, r.account).Service()
+		n := 0
+		f := func(e *api.Event) error {
+			// Each calendar is in order, so no more than q.max of each
+			// can be needed.
+			if q.max > 0 && n >= q.max {
+				return errEnough
+			}
+			n++
+			start, _ := eventStart(e)
+			all = append(all, calEvent{r.String(), start, e})
+			return nil
+		}
+		var err error
+		if q.parallel > 1 && !q.from.IsZero() && !q.to.IsZero() {
+			err = eachEventParallel(ctx, c, r.id, q, f)
+		} else {
+			err = eachEvent(ctx, c, r.id, q, f)
+		}
+		if err != nil && err != errEnough {
+			log.Fatalf("%s: %v", r, err)
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		if !all[i].start.Equal(all[j].start) {
+			return all[i].start.Before(all[j].start)
+		}
+		return all[i].ev.Id < all[j].ev.Id
+	})
+	if q.max > 0 && len(all) > q.max {
+		all = all[:q.max]
+	}
+	var found []string
+	for i, ce := range all {
+		e := ce.ev
+		if len(found) < maxListCompletions {
+			found = append(found, completionLine(e))
+		}
+		if jsonOut {
+			rec := newEventRecord("", e)
+			rec.Calendar = ce.cal
+			emitJSON(rec)
+			continue
+		}
+		if err := p.print(e, ce.cal, listLine(i, e, ce.cal)); err != nil {
+			log.Fatal(err)
+		}
+	}
+	saveCompletions("events", found)
+}
+
+// eachEvent calls f on each event matching q, in order of start time,
+// until f returns an error.
+func eachEvent(ctx context.Context, c *api.Service, calID string, q listQuery, f func(*api.Event) error) error {
+	call := c.Events.List(calID).Context(ctx)
+	call.SingleEvents(true)
+	call.OrderBy("startTime")
+	if !q.from.IsZero() {
+		call.TimeMin(q.from.Format(time.RFC3339))
+	}
+	if !q.to.IsZero() {
+		call.TimeMax(q.to.Format(time.RFC3339))
+	}
+	pageSize := q.pageSize
+	if q.max > 0 && q.max <= 2500 && (pageSize == 0 || q.max < pageSize) {
+		pageSize = q.max
+	}
+	if pageSize > 0 {
+		call.MaxResults(int64(pageSize))
+	}
+	// Events are handled a page at a time and not kept, so memory stays
+	// bounded by the page size however large the calendar is.
+	return call.Pages(ctx, func(events *api.Events) error {
+		for _, e := range events.Items {
+			if err := f(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// eachEventParallel is like eachEvent, but splits q's time range into
+// q.parallel parts and fetches them concurrently. Each part's events
+// are held in memory until the parts before it are done.
+func eachEventParallel(ctx context.Context, c *api.Service, calID string, q listQuery, f func(*api.Event) error) error {
+	n := q.parallel
+	step := q.to.Sub(q.from) / time.Duration(n)
+	parts := make([][]*api.Event, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for k := 0; k < n; k++ {
+		sub := q
+		sub.from = q.from.Add(time.Duration(k) * step)
+		if k < n-1 {
+			sub.to = sub.from.Add(step)
+		}
+		wg.Add(1)
+		go func(k int) {
+			defer wg.Done()
+			err := eachEvent(ctx, c, calID, sub, func(e *api.Event) error {
+				// An event that overlaps the start of this part belongs to
+				// the part in which it starts. The first part keeps events
+				// that started before the whole range, as eachEvent would.
+				if k > 0 {
+					if t, err := eventStart(e); err == nil && t.Before(sub.from) {
+						return nil
+					}
+				}
+				if q.max > 0 && len(parts[k]) >= q.max {
+					return errEnough
+				}
+				parts[k] = append(parts[k], e)
+				return nil
+			})
+			if err != errEnough {
+				errs[k] = err
+			}
+		}(k)
+	}
+	wg.Wait()
+	for k := range parts {
+		if errs[k] != nil {
+			return errs[k]
+		}
+		for _, e := range parts[k] {
+			if err := f(e); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sortTies makes the order of events that start at the same time
+// deterministic. Given events in order of start time, add holds each
+// group that starts together and passes it to f sorted by ID; flush
+// passes the last group.
+func sortTies(f func(*api.Event) error) (add func(*api.Event) error, flush func() error) {
+	var group []*api.Event
+	var groupStart time.Time
+	flush = func() error {
+		sort.Slice(group, func(i, j int) bool { return group[i].Id < group[j].Id })
+		for _, e := range group {
+			if err := f(e); err != nil {
+				return err
+			}
+		}
+		group = group[:0]
+		return nil
+	}
+	add = func(e *api.Event) error {
+		start, err := eventStart(e)
+		if err != nil || len(group) > 0 && !start.Equal(groupStart) {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		group = append(group, e)
+		groupStart = start
+		return nil
+	}
+	return add, flush
+}
+
+// eventStart returns the start of e. All-day events start at midnight
+// in inputLoc.
+func eventStart(e *api.Event) (time.Time, error) {
+	if e.Start == nil {
+		return time.Time{}, errors.New("no start")
+	}
+	if e.Start.Date != "" {
+		return time.ParseInLocation(time.DateOnly, e.Start.Date, inputLoc)
+	}
+	return parseDateTime(e.Start)
+}
+
+// eventTime returns dt as a string, converted to displayLoc if that is set.
+// All-day dates are not converted.
+func eventTime(dt *api.EventDateTime) string {
+	if dt.Date != "" {
+		return dt.Date
+	}
+	if displayLoc != nil {
+		if t, err := parseDateTime(dt); err == nil {
+			return t.In(displayLoc).Format(time.RFC3339)
+		}
+	}
+	return dt.DateTime
+}
+
+// calendarInfo is the JSON form of a calendar printed by cal calendars.
+type calendarInfo struct {
+	ID         string `json:"id"`
+	Summary    string `json:"summary"`
+	Primary    bool   `json:"primary"`
+	AccessRole string `json:"accessRole"`
+	TimeZone   string `json:"timeZone"`
+}
+
+// List all calendars that the authenticated user has access to.
+func listCalendars(ctx context.Context, c *api.Service) {
+	cals, err := readCalendars(ctx, c)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var ids []string
+	for _, e := range cals {
+		ids = append(ids, e.ID)
+	}
+	saveCompletions("calendars", ids)
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(cals); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	for i, e := range cals {
+		fmt.Printf("%d: ID:%q Primary:%t Summary:%q AccessRole:%s TimeZone:%s\n",
+			i, e.ID, e.Primary, e.Summary, e.AccessRole, e.TimeZone)
+	}
+}
+
+// readCalendars returns the calendars that the authenticated user has
+// access to, the primary calendar first and the rest by ID.
+func readCalendars(ctx context.Context, c *api.Service) ([]calendarInfo, error) {
+	var cals []calendarInfo
+	err := c.CalendarList.List().Pages(ctx, func(clist *api.CalendarList) error {
+		for _, e := range clist.Items {
+			cals = append(cals, calendarInfo{e.Id, e.Summary, e.Primary, e.AccessRole, e.TimeZone})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(cals, func(i, j int) bool {
+		if cals[i].Primary != cals[j].Primary {
+			return cals[i].Primary
+		}
+		return cals[i].ID < cals[j].ID
+	})
+	return cals, nil
+}
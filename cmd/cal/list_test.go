@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	api "google.golang.org/api/calendar/v3"
+	"google.golang.org/api/option"
+)
+
+// newPagingServer returns a Calendar API server whose calendar has n
+// events, served in pages of the requested size. Pages are encoded
+// ahead of time, so that the benchmarks measure the client.
+func newPagingServer(tb testing.TB, n int) *api.Service {
+	start := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	pages := map[string][]byte{} // by size and page token
+	encode := func(size, from int) []byte {
+		key := fmt.Sprintf("%d/%d", size, from)
+		if b, ok := pages[key]; ok {
+			return b
+		}
+		var es api.Events
+		for i := from; i < min(from+size, n); i++ {
+			t := start.Add(time.Duration(i) * time.Hour)
+			es.Items = append(es.Items, &api.Event{
+				Id:      fmt.Sprintf("ev%d", i),
+				Summary: fmt.Sprintf("Event %d", i),
+				Start:   &api.EventDateTime{DateTime: t.Format(time.RFC3339)},
+				End:     &api.EventDateTime{DateTime: t.Add(30 * time.Minute).Format(time.RFC3339)},
+			})
+		}
+		if from+size < n {
+			es.NextPageToken = strconv.Itoa(from + size)
+		}
+		b, err := json.Marshal(&es)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		pages[key] = b
+		return b
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		size := 250
+		if s := r.URL.Query().Get("maxResults"); s != "" {
+			size, _ = strconv.Atoi(s)
+		}
+		from, _ := strconv.Atoi(r.URL.Query().Get("pageToken"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(encode(size, from))
+	}))
+	tb.Cleanup(srv.Close)
+	c, err := api.NewService(context.Background(), option.WithEndpoint(srv.URL+"/"), option.WithHTTPClient(srv.Client()))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return c
+}
+
+// BenchmarkEachEvent lists a calendar of 50k events, a page at a time.
+func BenchmarkEachEvent(b *testing.B) {
+	const n = 50000
+	c := newPagingServer(b, n)
+	ctx := context.Background()
+	for _, size := range []int{250, 2500} {
+		b.Run(fmt.Sprintf("page-size=%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			var peak uint64
+			for i := 0; i < b.N; i++ {
+				got := 0
+				err := eachEvent(ctx, c, "primary", listQuery{pageSize: size}, func(*api.Event) error {
+					got++
+					if got%5000 == 0 {
+						var ms runtime.MemStats
+						runtime.ReadMemStats(&ms)
+						peak = max(peak, ms.HeapInuse)
+					}
+					return nil
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+				if got != n {
+					b.Fatalf("got %d events, want %d", got, n)
+				}
+			}
+			// Memory should stay bounded by the page size, not grow with
+			// the calendar. The peak includes the server's encoded pages,
+			// which are the same from run to run.
+			b.ReportMetric(float64(peak)/(1<<20), "peak-heap-MB")
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	api "google.golang.org/api/calendar/v3"
+)
+
+// A listedEvent is what a -format template is executed on, for each
+// event of a listing. Times are as in the listing's own lines.
+type listedEvent struct {
+	ID          string
+	Start, End  string
+	Status      string
+	Summary     string
+	Location    string
+	Description string
+	Calendar    string // for a listing of several calendars
+	Event       *api.Event
+}
+
+func newListedEvent(e *api.Event, cal string) listedEvent {
+	le := listedEvent{
+		ID:          e.Id,
+		Status:      e.Status,
+		Summary:     e.Summary,
+		Location:    e.Location,
+		Description: e.Description,
+		Calendar:    cal,
+		Event:       e,
+	}
+	if e.Start != nil {
+		le.Start = eventTime(e.Start)
+	}
+	if e.End != nil {
+		le.End = eventTime(e.End)
+	}
+	return le
+}
+
+// tsv returns the fields of le as a line of tab-separated values:
+// ID, start, end, status, calendar, summary, location. Tabs, newlines
+// and backslashes in the fields are escaped as \t, \n and \\, so every
+// event is one line of seven fields.
+func (le listedEvent) tsv() string {
+	fields := []string{le.ID, le.Start, le.End, le.Status, le.Calendar, le.Summary, le.Location}
+	for i, f := range fields {
+		fields[i] = tsvEscaper.Replace(f)
+	}
+	return strings.Join(fields, "\t")
+}
+
+var tsvEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// A listPrinter prints the text lines of cal list and cal search.
+type listPrinter struct {
+	tmpl  *template.Template // from -format; nil for the command's own lines
+	tsv   bool               // from -o tsv
+	end   string             // what ends each line: a newline, or NUL for -print0
+	color *colorizer         // nil for no color
+}
+
+// newListPrinter returns a listPrinter for the -format template format,
+// which may be empty, the -o output format, and -print0.
+func newListPrinter(format, output string, print0 bool) (*listPrinter, error) {
+	p := &listPrinter{end: "\n"}
+	if print0 {
+		p.end = "\x00"
+	}
+	switch output {
+	case "text":
+	case "tsv":
+		if format != "" {
+			return nil, fmt.Errorf("-format and -o tsv don't go together")
+		}
+		p.tsv = true
+	default:
+		return nil, fmt.Errorf("-o: unknown output format %q; want text or tsv", output)
+	}
+	if format != "" {
+		t, err := template.New("format").Parse(format)
+		if err != nil {
+			return nil, fmt.Errorf("-format: %v", err)
+		}
+		p.tmpl = t
+	} else if !p.tsv && !print0 && useColor() {
+		p.color = newColorizer()
+	}
+	return p, nil
+}
+
+// print prints e, of calendar cal, as line unless -format or -o says
+// otherwise.
+func (p *listPrinter) print(e *api.Event, cal, line string) error {
+	switch {
+	case p.tmpl != nil:
+		var b strings.Builder
+		if err := p.tmpl.Execute(&b, newListedEvent(e, cal)); err != nil {
+			return err
+		}
+		line = b.String()
+	case p.tsv:
+		line = newListedEvent(e, cal).tsv()
+	case p.color != nil:
+		line = p.color.paint(e, line)
+	}
+	_, err := fmt.Fprint(os.Stdout, line, p.end)
+	return err
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	api "google.golang.org/api/calendar/v3"
+)
+
+var (
+	loadFlags    = flag.NewFlagSet("load", flag.ExitOnError)
+	loadWeekly   = loadFlags.Bool("weekly", false, "report by week instead of by day")
+	loadFrom     = loadFlags.String("from", "", "first day to report (default the Monday of this week)")
+	loadWeeks    = loadFlags.Int("weeks", 4, "number of weeks to report")
+	loadWorkday  = loadFlags.String("workday", "9-17", "working hours; meetings outside them are after hours")
+	loadMaxHours = loadFlags.Float64("max-hours", 0, "warn about weeks with more meeting hours than this (0 for no limit)")
+)
+
+// Meetings with gaps no longer than this between them are back to back.
+const backToBackGap = 5 * time.Minute
+
+// A meeting is a timed event that keeps its calendar busy: not marked
+// free, and not declined.
+type meeting struct {
+	start, end time.Time
+}
+
+// A load summarizes the meetings of a day or week.
+type load struct {
+	hours      float64 // total meeting hours
+	meetings   int
+	streak     time.Duration // longest run of back-to-back meetings
+	afterHours int           // meetings that start or end outside the workday
+}
+
+// A loadRecord is the -json form of a line of cal load.
+type loadRecord struct {
+	Start      string  `json:"start"`
+	Meetings   int     `json:"meetings"`
+	Hours      float64 `json:"hours"`
+	Streak     string  `json:"longestBackToBack"`
+	AfterHours int     `json:"afterHours"`
+	Over       bool    `json:"over,omitempty"`
+}
+
+func runLoad(ctx context.Context, args []string) {
+	first, last, err := parseHours(*loadWorkday)
+	if err != nil {
+		log.Fatalf("-workday: %v", err)
+	}
+	from := weekStart(now())
+	if *loadFrom != "" {
+		if from, err = time.ParseInLocation(time.DateOnly, *loadFrom, inputLoc); err != nil {
+			log.Fatalf("-from: %v", err)
+		}
+	}
+	to := from.AddDate(0, 0, 7**loadWeeks)
+	ms, err := readMeetings(ctx, newService(ctx), calID, from, to)
+	if err != nil {
+		log.Fatal(err)
+	}
+	step := 1
+	label := "Mon Jan 2"
+	if *loadWeekly {
+		step, label = 7, "week of Jan 2"
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "\tmeetings\thours\tlongest back-to-back\tafter hours\t")
+	over := 0
+	for d := from; d.Before(to); d = d.AddDate(0, 0, step) {
+		l := meetingLoad(ms, d, d.AddDate(0, 0, step), first, last)
+		warn := ""
+		if *loadWeekly && *loadMaxHours > 0 && l.hours > *loadMaxHours {
+			warn = fmt.Sprintf("over %g hours", *loadMaxHours)
+			over++
+		}
+		if jsonOut {
+			emitJSON(loadRecord{d.Format(time.DateOnly), l.meetings, l.hours, l.streak.String(), l.afterHours, warn != ""})
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%.1f\t%s\t%d\t%s\n", d.Format(label), l.meetings, l.hours, l.streak, l.afterHours, warn)
+	}
+	tw.Flush()
+	if !*loadWeekly && *loadMaxHours > 0 {
+		for w := from; w.Before(to); w = w.AddDate(0, 0, 7) {
+			if l := meetingLoad(ms, w, w.AddDate(0, 0, 7), first, last); l.hours > *loadMaxHours {
+				slog.Warn(fmt.Sprintf("week of %s has %.1f meeting hours, over %g", w.Format("Jan 2"), l.hours, *loadMaxHours))
+				over++
+			}
+		}
+	}
+	if over > 0 {
+		os.Exit(1)
+	}
+}
+
+// readMeetings returns the meetings of the calendar between from and to,
+// in order of start.
+func readMeetings(ctx context.Context, c *api.Service, calID string, from, to time.Time) ([]meeting, error) {
+	var ms []meeting
+	err := eachEvent(ctx, c, calID, listQuery{from: from, to: to}, func(e *api.Event) error {
+		if m, ok := eventMeeting(e); ok {
+			ms = append(ms, m)
+		}
+		return nil
+	})
+	return ms, err
+}
+
+// eventMeeting returns e as a meeting, if it is one.
+func eventMeeting(e *api.Event) (meeting, bool) {
+	if e.Start == nil || e.End == nil || e.Start.Date != "" || e.Transparency == "transparent" || e.Status == "cancelled" {
+		return meeting{}, false
+	}
+	for _, a := range e.Attendees {
+		if a.Self && a.ResponseStatus == "declined" {
+			return meeting{}, false
+		}
+	}
+	s, err1 := parseDateTime(e.Start)
+	t, err2 := parseDateTime(e.End)
+	if err1 != nil || err2 != nil {
+		return meeting{}, false
+	}
+	return meeting{s, t}, true
+}
+
+// meetingLoad summarizes the meetings that start in [from, to). The
+// workday runs from hour first to hour last.
+func meetingLoad(ms []meeting, from, to time.Time, first, last int) load {
+	var in []meeting
+	for _, m := range ms {
+		if !m.start.Before(from) && m.start.Before(to) {
+			in = append(in, m)
+		}
+	}
+	sort.Slice(in, func(i, j int) bool { return in[i].start.Before(in[j].start) })
+	var l load
+	var runStart, runEnd time.Time
+	for i, m := range in {
+		l.meetings++
+		l.hours += m.end.Sub(m.start).Hours()
+		if i == 0 || m.start.Sub(runEnd) > backToBackGap {
+			runStart = m.start
+		}
+		if i == 0 || m.end.After(runEnd) {
+			runEnd = m.end
+		}
+		l.streak = max(l.streak, runEnd.Sub(runStart))
+		s, e := m.start.In(inputLoc), m.end.In(inputLoc)
+		dayStart := time.Date(s.Year(), s.Month(), s.Day(), first, 0, 0, 0, inputLoc)
+		dayEnd := time.Date(s.Year(), s.Month(), s.Day(), last, 0, 0, 0, inputLoc)
+		if s.Before(dayStart) || e.After(dayEnd) {
+			l.afterHours++
+		}
+	}
+	return l
+}
+
+// warnMeetingLoad warns, with info, about each week in which adding evs
+// to the calendar would make it have more than maxHours of meetings.
+func warnMeetingLoad(ctx context.Context, c *api.Service, calID string, evs []*api.Event, maxHours float64) error {
+	var added []meeting
+	for _, e := range evs {
+		if m, ok := eventMeeting(e); ok {
+			added = append(added, m)
+		}
+	}
+	if len(added) == 0 {
+		return nil
+	}
+	weeks := map[time.Time]bool{}
+	for _, m := range added {
+		weeks[weekStart(m.start)] = true
+	}
+	var ws []time.Time
+	for w := range weeks {
+		ws = append(ws, w)
+	}
+	sort.Slice(ws, func(i, j int) bool { return ws[i].Before(ws[j]) })
+	for _, w := range ws {
+		end := w.AddDate(0, 0, 7)
+		ms, err := readMeetings(ctx, c, calID, w, end)
+		if err != nil {
+			return err
+		}
+		before := meetingLoad(ms, w, end, 0, 24).hours
+		after := meetingLoad(append(ms, added...), w, end, 0, 24).hours
+		if after > maxHours {
+			info("warning: %s: the week of %s would have %.1f meeting hours (%.1f now), over %g\n",
+				calID, w.Format("Jan 2"), after, before, maxHours)
+		}
+	}
+	return nil
+}
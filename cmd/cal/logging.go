@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/jba/calendar"
+)
+
+// Logging flags, shared by all commands.
+var (
+	verbose   bool   // -v: also log debugging detail, like each API request
+	quiet     bool   // -q: log only errors, and print no progress messages
+	logFormat string // -log-format: text or json
+)
+
+// setupLogging sends everything logged, through slog or the log package,
+// to standard error in the form chosen by -log-format. Messages from the
+// log package are all errors, so that -q keeps them; warnings are
+// logged with slog.Warn.
+func setupLogging() error {
+	level := slog.LevelInfo
+	switch {
+	case verbose && quiet:
+		return fmt.Errorf("-v and -q are mutually exclusive")
+	case verbose:
+		level = slog.LevelDebug
+	case quiet:
+		level = slog.LevelError
+	}
+	opts := &slog.HandlerOptions{Level: level}
+	format := logFormat
+	if jsonOut && format == "" {
+		format = "json"
+	}
+	var h slog.Handler
+	switch format {
+	case "", "text":
+		h = &plainHandler{slog.NewTextHandler(os.Stderr, opts)}
+	case "json":
+		h = slog.NewJSONHandler(os.Stderr, opts)
+	default:
+		return fmt.Errorf("-log-format: unknown format %q; choices are text, json", logFormat)
+	}
+	slog.SetDefault(slog.New(h))
+	slog.SetLogLoggerLevel(slog.LevelError)
+	return nil
+}
+
+// plainHandler prints messages as the log package always has,
+// like "2018/01/17 17:30:00 message", unless there are attributes.
+type plainHandler struct {
+	*slog.TextHandler
+}
+
+func (h *plainHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.NumAttrs() > 0 {
+		return h.TextHandler.Handle(ctx, r)
+	}
+	_, err := fmt.Fprintf(os.Stderr, "%s %s\n", r.Time.Format("2006/01/02 15:04:05"), r.Message)
+	return err
+}
+
+// logCalls is calendar middleware that logs each change at debug level.
+func logCalls(ctx context.Context, call *calendar.Call, next func(context.Context) error) error {
+	start := time.Now()
+	err := next(ctx)
+	attrs := []any{"op", call.Op, "calendar", call.CalendarID, "duration", time.Since(start)}
+	if call.Destination != "" {
+		attrs = append(attrs, "destination", call.Destination)
+	}
+	if call.EventID != "" {
+		attrs = append(attrs, "event", call.EventID)
+	} else if call.Result != nil {
+		attrs = append(attrs, "event", call.Result.Id)
+	}
+	if err != nil {
+		attrs = append(attrs, "error", err)
+	}
+	slog.Debug("calendar call", attrs...)
+	return err
+}
+
+// loggingTransport logs each request at debug level.
+type loggingTransport struct {
+	base http.RoundTripper
+}
+
+func (t loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	res, err := t.base.RoundTrip(req)
+	attrs := []any{"method", req.Method, "url", req.URL.Redacted(), "duration", time.Since(start)}
+	if err != nil {
+		slog.Debug("request failed", append(attrs, "error", err)...)
+		return nil, err
+	}
+	slog.Debug("request", append(attrs, "status", res.StatusCode)...)
+	return res, nil
+}
+
+// logged returns rt, wrapped to log requests if -v is set.
+func logged(rt http.RoundTripper) http.RoundTripper {
+	if !verbose {
+		return rt
+	}
+	return loggingTransport{rt}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	api "google.golang.org/api/calendar/v3"
+)
+
+// readMarkdown parses events from a Markdown agenda:
+//
+//	# 2026 October 20
+//
+//	- 7:00pm–9:00pm Rehearsal — bring the new scores
+//	- 9:30pm Notes call
+//	  - indented lines go in the description
+//
+// A heading that parses as a date, with the grammars of -grammars, starts
+// a day. Each list item under it that begins with a time or range of times
+// is an event; the rest of the item is the summary, then, after an em dash
+// or "--", the description; see markdownTimes. Other headings, and
+// everything under them, are ignored, as are other lines and items whose
+// times don't parse, so an agenda can sit among notes.
+func readMarkdown(data []byte) ([]*api.Event, error) {
+	var (
+		evs  []*api.Event
+		date string // of the current heading, if it is a date
+		item string // the current list item, if it is under a date
+		more []string
+		num  int // line number of item
+	)
+	flush := func() error {
+		if item == "" {
+			return nil
+		}
+		ev, err := markdownEvent(date, item, more)
+		if err != nil {
+			return fmt.Errorf("line %d: %v", num, err)
+		}
+		if ev != nil {
+			evs = append(evs, ev)
+		}
+		item, more = "", nil
+		return nil
+	}
+	for n, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		indented := strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")
+		if item != "" && indented {
+			// An indented line continues the item.
+			text := strings.TrimSpace(line)
+			if t, ok := strings.CutPrefix(text, "- "); ok {
+				text = t
+			}
+			more = append(more, text)
+			continue
+		}
+		if err := flush(); err != nil {
+			return nil, err
+		}
+		if h, ok := markdownHeading(line); ok {
+			date = ""
+			if _, err := parseTime(h + " 12am"); err == nil {
+				date = h
+			}
+			continue
+		}
+		if date == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* ") {
+			item, num = strings.TrimSpace(line[2:]), n+1
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return evs, nil
+}
+
+// markdownHeading returns the text of an ATX heading like "## Monday".
+func markdownHeading(line string) (string, bool) {
+	h := strings.TrimLeft(line, "#")
+	if len(h) == len(line) || len(line)-len(h) > 6 || !strings.HasPrefix(h, " ") {
+		return "", false
+	}
+	return strings.TrimSpace(strings.TrimRight(h, "#")), true
+}
+
+// A markdownTimes matches the times at the start of a list item, like
+// "7pm", "7:00pm–9:00pm", "7-9pm", or "19:00 - 21:00". A time needs a
+// colon or am or pm, so that an item like "3 chairs" is not an event,
+// except at the start of a range whose end has one.
+var markdownTimes = regexp.MustCompile(`(?i)^(?:(\d{1,2}(?::\d\d)?\s*(?:[ap]m)?)\s*[-–]\s*(\d{1,2}(?::\d\d\s*(?:[ap]m)?|\s*[ap]m))|(\d{1,2}(?::\d\d\s*(?:[ap]m)?|\s*[ap]m)))\s+(.*)$`)
+
+// markdownEvent returns the event for a list item under the heading date,
+// with more lines of description, or nil if the item doesn't begin with
+// a time.
+func markdownEvent(date, item string, more []string) (*api.Event, error) {
+	m := markdownTimes.FindStringSubmatch(item)
+	if m == nil {
+		return nil, nil
+	}
+	start, end := m[1], m[2]
+	if start == "" {
+		start = m[3]
+	}
+	// Write the times as the grammars expect them. A start without am or
+	// pm takes the end's.
+	st, err := parseClock(time.Time{}, start, end)
+	if err != nil {
+		return nil, nil
+	}
+	start = st.Format("3:04pm")
+	if end != "" {
+		et, err := parseClock(time.Time{}, end, end)
+		if err != nil {
+			return nil, nil
+		}
+		end = et.Format("3:04pm")
+	}
+	summary, desc := m[4], ""
+	for _, sep := range []string{" — ", " -- "} {
+		if s, d, ok := strings.Cut(summary, sep); ok {
+			summary, desc = strings.TrimSpace(s), strings.TrimSpace(d)
+			break
+		}
+	}
+	desc = strings.TrimSpace(strings.Join(append([]string{desc}, more...), "\n"))
+	fields := map[string]string{
+		"date":    date,
+		"start":   start,
+		"end":     end,
+		"summary": summary,
+		"desc":    desc,
+	}
+	return fieldsEvent(func(f string) string { return fields[f] })
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+)
+
+var (
+	moveFlags = flag.NewFlagSet("move", flag.ExitOnError)
+	moveTo    = moveFlags.String("to", "", "ID or alias of the calendar to move the events to")
+	moveDoit  = moveFlags.Bool("doit", false, "nothing is moved unless this is provided")
+	moveStdin = moveFlags.Bool("stdin", false, "also read event IDs from standard input, one per line")
+)
+
+func runMove(ctx context.Context, args []string) {
+	if *moveTo == "" {
+		log.Fatal("need -to")
+	}
+	ref, err := parseCalendarRef(*moveTo)
+	if err != nil {
+		log.Fatalf("-to: %v", err)
+	}
+	if ref.account != idAccount {
+		log.Fatal("can't move events to another account; use cal copy, then cal delete")
+	}
+	dest := ref.id
+	if dest == calID {
+		log.Fatal("-to is the same calendar as -id")
+	}
+	ids := args
+	if *moveStdin {
+		in, err := readIDs(os.Stdin)
+		if err != nil {
+			log.Fatal(err)
+		}
+		ids = append(ids, in...)
+	}
+	if len(ids) == 0 {
+		log.Fatal("no event IDs")
+	}
+	client := newClient(ctx)
+	failed := 0
+	fail := func(eid string, err error) {
+		failed++
+		if jsonOut {
+			emitJSON(eventRecord{Action: "failed", Calendar: calID, ID: eid, Error: err.Error()})
+			return
+		}
+		log.Printf("%s: %v", eid, err)
+	}
+	for _, eid := range ids {
+		if !*moveDoit {
+			ev, err := client.Service().Events.Get(calID, eid).Context(ctx).Do()
+			if err != nil {
+				fail(eid, err)
+				continue
+			}
+			if jsonOut {
+				r := newEventRecord("would move", ev)
+				r.Calendar = dest
+				emitJSON(r)
+				continue
+			}
+			fmt.Printf("would move %s\t%s\t%q\n", eid, eventTime(ev.Start), ev.Summary)
+			continue
+		}
+		ev, err := client.MoveEvent(ctx, calID, eid, dest)
+		if err != nil {
+			fail(eid, err)
+			continue
+		}
+		if jsonOut {
+			r := newEventRecord("moved", ev)
+			r.Calendar = dest
+			emitJSON(r)
+			continue
+		}
+		fmt.Printf("moved %s\t%s\t%q\n", eid, eventTime(ev.Start), ev.Summary)
+	}
+	if !*moveDoit {
+		info("provide -doit to move to %s\n", dest)
+	} else {
+		info("moved %d events to %s.\n", len(ids)-failed, dest)
+	}
+	switch {
+	case failed == len(ids):
+		os.Exit(1)
+	case failed > 0:
+		os.Exit(exitPartialFailure)
+	}
+}
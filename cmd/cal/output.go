@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	api "google.golang.org/api/calendar/v3"
+)
+
+// If true, from -json, commands write JSON to standard output instead of
+// text: one object per line for each event they list or act on. Progress
+// messages go to standard error, and log messages are JSON too unless
+// -log-format says otherwise.
+var jsonOut bool
+
+// info prints a progress message: to standard output normally, but to
+// standard error with -json, so that standard output is only JSON. With
+// -q it prints nothing.
+func info(format string, args ...any) {
+	if quiet {
+		return
+	}
+	fmt.Fprintf(infoWriter(), format, args...)
+}
+
+// infoWriter returns where info writes.
+func infoWriter() io.Writer {
+	if jsonOut {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// emitJSON writes v to standard output as one line of JSON.
+func emitJSON(v any) {
+	if err := json.NewEncoder(os.Stdout).Encode(v); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// An eventRecord is the -json form of an event that a command listed or
+// acted on.
+type eventRecord struct {
+	Action   string `json:"action,omitempty"` // e.g. "inserted", "would delete", "failed"
+	Calendar string `json:"calendar,omitempty"`
+	ID       string `json:"id,omitempty"`
+	Start    string `json:"start,omitempty"`
+	End      string `json:"end,omitempty"`
+	Status   string `json:"status,omitempty"`
+	Summary  string `json:"summary,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+func newEventRecord(action string, ev *api.Event) eventRecord {
+	r := eventRecord{Action: action, ID: ev.Id, Status: ev.Status, Summary: ev.Summary}
+	if ev.Start != nil {
+		r.Start = eventTime(ev.Start)
+	}
+	if ev.End != nil {
+		r.End = eventTime(ev.End)
+	}
+	return r
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	api "google.golang.org/api/calendar/v3"
+)
+
+var (
+	overlayFlags    = flag.NewFlagSet("overlay", flag.ExitOnError)
+	overlayAccounts = overlayFlags.String("accounts", "", "comma-separated accounts from the config file to overlay; default means the one given by -creds")
+	overlayWeek     = overlayFlags.Bool("week", false, "start on the Monday of the week of -from")
+	overlayFrom     = overlayFlags.String("from", "now", "first day to show")
+	overlayDays     = overlayFlags.Int("days", 7, "number of days to show")
+	overlayHours    = overlayFlags.String("hours", "8-20", "hours of the day to show, like 8-20")
+)
+
+// Width of a cell of the overlay grid.
+const overlayStep = 15 * time.Minute
+
+func runOverlay(ctx context.Context, args []string) {
+	if *overlayAccounts == "" {
+		log.Fatal("need -accounts")
+	}
+	var accts []string
+	for _, a := range strings.Split(*overlayAccounts, ",") {
+		a = strings.TrimSpace(a)
+		switch _, ok := accounts[a]; {
+		case a == "default":
+			if credsFile == "" && credsCmd == "" {
+				log.Fatal("-accounts default: need -creds or -creds-command")
+			}
+		case !ok:
+			log.Fatalf("-accounts: no account %q in %s", a, configFile())
+		}
+		accts = append(accts, a)
+	}
+	from, err := parseListTime(*overlayFrom)
+	if err != nil {
+		log.Fatalf("-from: %v", err)
+	}
+	loc := displayLoc
+	if loc == nil {
+		loc = inputLoc
+	}
+	if *overlayWeek {
+		from = weekStart(from)
+	}
+	from = from.In(loc)
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+	to := from.AddDate(0, 0, *overlayDays)
+	first, last, err := parseHours(*overlayHours)
+	if err != nil {
+		log.Fatalf("-hours: %v", err)
+	}
+
+	busy := map[string][]*api.TimePeriod{}
+	for _, a := range accts {
+		acct := a
+		if acct == "default" {
+			acct = ""
+		}
+		busy[a], err = freeBusy(ctx, newClientFor(ctx, acct).Service(), from, to)
+		if err != nil {
+			log.Fatalf("%s: %v", a, err)
+		}
+	}
+	overlay(accts, busy, from, *overlayDays, first, last, loc)
+}
+
+// parseHours parses a range of hours like 8-20.
+func parseHours(s string) (first, last int, err error) {
+	a, b, ok := strings.Cut(s, "-")
+	if ok {
+		first, err = strconv.Atoi(a)
+		if err == nil {
+			last, err = strconv.Atoi(b)
+		}
+	}
+	if !ok || err != nil || first < 0 || last > 24 || first >= last {
+		return 0, 0, fmt.Errorf("bad hours %q: want a range like 8-20", s)
+	}
+	return first, last, nil
+}
+
+// freeBusy returns the busy periods of the primary calendar of the
+// account that c is authorized for.
+func freeBusy(ctx context.Context, c *api.Service, from, to time.Time) ([]*api.TimePeriod, error) {
+	res, err := c.Freebusy.Query(&api.FreeBusyRequest{
+		TimeMin: from.Format(time.RFC3339),
+		TimeMax: to.Format(time.RFC3339),
+		Items:   []*api.FreeBusyRequestItem{{Id: "primary"}},
+	}).Context(ctx).Do()
+	if err != nil {
+		return nil, err
+	}
+	fb := res.Calendars["primary"]
+	if len(fb.Errors) > 0 {
+		return nil, fmt.Errorf("free/busy: %s", fb.Errors[0].Reason)
+	}
+	return fb.Busy, nil
+}
+
+// overlay prints a grid with a row per day and a column per overlayStep
+// from hour first to hour last. A cell shows the letter of the one
+// account that is busy then, # if more than one is, or . if none is.
+// After the grid come the conflicts: the times when accounts overlap.
+func overlay(accts []string, busy map[string][]*api.TimePeriod, from time.Time, days, first, last int, loc *time.Location) {
+	letters := accountLetters(accts)
+	type span struct{ start, end time.Time }
+	spans := map[string][]span{}
+	for _, a := range accts {
+		for _, p := range busy[a] {
+			s, err1 := time.Parse(time.RFC3339, p.Start)
+			e, err2 := time.Parse(time.RFC3339, p.End)
+			if err1 == nil && err2 == nil {
+				spans[a] = append(spans[a], span{s, e})
+			}
+		}
+	}
+	busyAt := func(a string, s, e time.Time) bool {
+		for _, sp := range spans[a] {
+			if sp.start.Before(e) && sp.end.After(s) {
+				return true
+			}
+		}
+		return false
+	}
+
+	cells := (last - first) * int(time.Hour/overlayStep)
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-11s", "")
+	for h := first; h < last; h++ {
+		fmt.Fprintf(&b, "%-*d", int(time.Hour/overlayStep), h)
+	}
+	fmt.Println(strings.TrimRight(b.String(), " "))
+	for d := 0; d < days; d++ {
+		day := from.AddDate(0, 0, d)
+		b.Reset()
+		fmt.Fprintf(&b, "%-11s", day.Format("Mon Jan 2"))
+		start := time.Date(day.Year(), day.Month(), day.Day(), first, 0, 0, 0, loc)
+		for i := 0; i < cells; i++ {
+			s := start.Add(time.Duration(i) * overlayStep)
+			e := s.Add(overlayStep)
+			c := '.'
+			for _, a := range accts {
+				if busyAt(a, s, e) {
+					if c == '.' {
+						c = letters[a]
+					} else {
+						c = '#'
+					}
+				}
+			}
+			b.WriteRune(c)
+		}
+		fmt.Println(b.String())
+	}
+	var legend []string
+	for _, a := range accts {
+		legend = append(legend, fmt.Sprintf("%c %s", letters[a], a))
+	}
+	fmt.Printf("\n%s, # conflict\n", strings.Join(legend, ", "))
+
+	// Sweep over the starts and ends of the busy periods, keeping the
+	// accounts busy at each moment.
+	type edge struct {
+		t     time.Time
+		acct  string
+		delta int
+	}
+	var edges []edge
+	for _, a := range accts {
+		for _, sp := range spans[a] {
+			edges = append(edges, edge{sp.start, a, 1}, edge{sp.end, a, -1})
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if !edges[i].t.Equal(edges[j].t) {
+			return edges[i].t.Before(edges[j].t)
+		}
+		return edges[i].delta < edges[j].delta // ends first, so touching isn't a conflict
+	})
+	active := map[string]int{}
+	var conflicts []string
+	var cstart time.Time
+	var cwho string
+	for _, e := range edges {
+		before := busyNames(accts, active)
+		active[e.acct] += e.delta
+		after := busyNames(accts, active)
+		if after == before {
+			continue
+		}
+		if strings.Contains(before, ",") && !cstart.Equal(e.t) {
+			conflicts = append(conflicts, fmt.Sprintf("%s-%s\t%s",
+				cstart.In(loc).Format("Mon Jan 2 15:04"), e.t.In(loc).Format("15:04"), cwho))
+		}
+		cstart, cwho = e.t, after
+	}
+	if len(conflicts) == 0 {
+		fmt.Println("no conflicts")
+		return
+	}
+	fmt.Println("\nconflicts:")
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	for _, c := range conflicts {
+		fmt.Fprintln(tw, c)
+	}
+	tw.Flush()
+}
+
+// busyNames returns the accounts with a positive count in active, in
+// order, joined by commas.
+func busyNames(accts []string, active map[string]int) string {
+	var names []string
+	for _, a := range accts {
+		if active[a] > 0 {
+			names = append(names, a)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// accountLetters assigns each account a letter for the overlay grid: its
+// first letter if that is unique, or else a digit.
+func accountLetters(accts []string) map[string]rune {
+	count := map[rune]int{}
+	for _, a := range accts {
+		count[firstLetter(a)]++
+	}
+	m := map[string]rune{}
+	for i, a := range accts {
+		l := firstLetter(a)
+		if count[l] > 1 || l == '#' || l == '.' {
+			l = rune('1' + i%9)
+		}
+		m[a] = l
+	}
+	return m
+}
+
+func firstLetter(s string) rune {
+	for _, r := range strings.ToLower(s) {
+		return r
+	}
+	return '?'
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	api "google.golang.org/api/calendar/v3"
+)
+
+var overviewFlags = flag.NewFlagSet("overview", flag.ExitOnError)
+
+func runOverview(ctx context.Context, args []string) {
+	overview(ctx, newService(ctx))
+}
+
+// An overviewRecord is the -json form of a line of cal overview.
+type overviewRecord struct {
+	Calendar   string       `json:"calendar"`
+	Summary    string       `json:"summary,omitempty"`
+	AccessRole string       `json:"accessRole"`
+	ThisWeek   int          `json:"thisWeek"`
+	Next       *eventRecord `json:"next,omitempty"`
+	Sync       string       `json:"sync"`
+	Error      string       `json:"error,omitempty"`
+}
+
+// overview prints a line for each calendar the user has access to, with
+// the number of events this week, the next event, and the sync status:
+// "unchanged since" the time the cache last saw this week's events,
+// "fresh" if they were fetched anew, or "no cache".
+func overview(ctx context.Context, c *api.Service) {
+	cals, err := readCalendars(ctx, c)
+	if err != nil {
+		log.Fatal(err)
+	}
+	now := now()
+	from := weekStart(now)
+	to := from.AddDate(0, 0, 7)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	if !jsonOut {
+		fmt.Fprintln(tw, "calendar\trole\tthis week\tnext\tsync")
+	}
+	for _, cal := range cals {
+		r := overviewRecord{Calendar: cal.ID, Summary: cal.Summary, AccessRole: cal.AccessRole}
+		err := overviewCalendar(ctx, c, &r, now, from, to)
+		if err != nil {
+			r.Error = err.Error()
+		}
+		if jsonOut {
+			emitJSON(r)
+			continue
+		}
+		next := "-"
+		if r.Next != nil {
+			next = r.Next.Start + " " + r.Next.Summary
+		}
+		if err != nil {
+			next = "error: " + r.Error
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n", cal.ID, cal.AccessRole, r.ThisWeek, next, r.Sync)
+	}
+	tw.Flush()
+}
+
+// overviewCalendar fills in r for the calendar r.Calendar.
+func overviewCalendar(ctx context.Context, c *api.Service, r *overviewRecord, now, from, to time.Time) error {
+	call := c.Events.List(r.Calendar).Context(ctx).SingleEvents(true).
+		TimeMin(from.Format(time.RFC3339)).TimeMax(to.Format(time.RFC3339))
+	r.Sync = "no cache"
+	first := true
+	err := call.Pages(ctx, func(events *api.Events) error {
+		if first && !noCache && cacheDir() != "" {
+			r.Sync = "fresh"
+			if t := events.Header.Get(cachedHeader); t != "" {
+				r.Sync = "unchanged since " + t
+			}
+		}
+		first = false
+		r.ThisWeek += len(events.Items)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	next, err := c.Events.List(r.Calendar).Context(ctx).SingleEvents(true).OrderBy("startTime").
+		TimeMin(now.Format(time.RFC3339)).MaxResults(1).Do()
+	if err != nil {
+		return err
+	}
+	if len(next.Items) > 0 {
+		rec := newEventRecord("", next.Items[0])
+		r.Next = &rec
+	}
+	return nil
+}
+
+// weekStart returns midnight on the Monday of t's week, in inputLoc.
+func weekStart(t time.Time) time.Time {
+	t = t.In(inputLoc)
+	days := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-days, 0, 0, 0, 0, inputLoc)
+}
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jba/calendar"
+	api "google.golang.org/api/calendar/v3"
+)
+
+// File format: blank-line-separated events, each of which is:
+//
+//	2018 January 19
+//	7:00pm – 9:00pm
+//	summary
+//	optional description line 1
+//	optional description line 2
+//	...
+//
+// The time line may have just a start time, like "7:00pm".
+// A description line can instead set a field of the event, like
+// "status: tentative", "color: tomato" or
+// "url: https://example.com/schedule"; see fieldSetters.
+// See expandSummaries for tokens that can appear in summaries.
+//
+// Files with other extensions are read in other formats: .json (JSON-LD
+// or calendar.Event JSON), .jsonld, .jsonl or .ndjson, .md or .markdown,
+// .yaml or .yml, .rem or .remind, .csv, and .ics or .ical. The filename
+// "-" reads standard input.
+//
+// The filename can also be a URL; see readSource.
+func readEventFile(filename string) ([]*api.Event, error) {
+	bytes, err := readSource(filename)
+	if err != nil {
+		return nil, err
+	}
+	var evs []*api.Event
+	switch sourceExt(filename) {
+	case ".jsonld":
+		evs, err = readJSONLD(bytes)
+		if err != nil {
+			return nil, err
+		}
+	case ".json":
+		if isJSONLD(bytes) {
+			evs, err = readJSONLD(bytes)
+		} else {
+			evs, err = readJSONEvents(bytes, false)
+		}
+		if err != nil {
+			return nil, err
+		}
+	case ".jsonl", ".ndjson":
+		evs, err = readJSONEvents(bytes, true)
+		if err != nil {
+			return nil, err
+		}
+	case ".md", ".markdown":
+		evs, err = readMarkdown(bytes)
+		if err != nil {
+			return nil, err
+		}
+	case ".yaml", ".yml":
+		evs, err = readYAML(bytes)
+		if err != nil {
+			return nil, err
+		}
+	case ".rem", ".remind":
+		evs, err = readRemind(bytes)
+		if err != nil {
+			return nil, err
+		}
+	case ".csv":
+		evs, err = readCSV(bytes)
+		if err != nil {
+			return nil, err
+		}
+	case ".ics", ".ical":
+		evs, err = readICS(bytes)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		// Walk the events in place rather than splitting the file into
+		// a slice of them first; generated files can be large.
+		s := strings.TrimRight(string(bytes), "\n")
+		evs = make([]*api.Event, 0, strings.Count(s, "\n\n")+1)
+		for {
+			sev, rest, more := strings.Cut(s, "\n\n")
+			e, err := parseEvent(sev)
+			if err != nil {
+				return nil, err
+			}
+			evs = append(evs, e)
+			if !more {
+				break
+			}
+			s = rest
+		}
+	}
+	expandSummaries(evs)
+	return evs, nil
+}
+
+// expandSummaries replaces tokens in event summaries:
+//
+//	{n}     the event's number among the events with the same summary,
+//	        starting at 1, as in "Lecture {n}"
+//	{date}  the event's start date, like "January 17"
+func expandSummaries(evs []*api.Event) {
+	counts := map[string]int{}
+	for _, ev := range evs {
+		if !strings.Contains(ev.Summary, "{") {
+			continue
+		}
+		counts[ev.Summary]++
+		summary := ev.Summary
+		if strings.Contains(summary, "{n}") {
+			summary = strings.ReplaceAll(summary, "{n}", strconv.Itoa(counts[ev.Summary]))
+		}
+		if strings.Contains(summary, "{date}") {
+			date := ev.Start.Date
+			if t, err := time.Parse(time.DateOnly, date); err == nil {
+				date = t.Format("January 2")
+			} else if t, err := parseDateTime(ev.Start); err == nil {
+				date = t.Format("January 2")
+			}
+			summary = strings.ReplaceAll(summary, "{date}", date)
+		}
+		ev.Summary = summary
+	}
+}
+
+func parseEvent(e string) (*api.Event, error) {
+	lines := strings.Split(e, "\n")
+	// Trim whitespace, replace en-dash with hyphen.
+	for i := range lines {
+		lines[i] = strings.Replace(strings.TrimSpace(lines[i]),
+			"–", "-", -1)
+	}
+	if len(lines) < 3 {
+		return nil, fmt.Errorf("too few lines: %q", e)
+	}
+	date := lines[0]
+	times := strings.Split(lines[1], "-")
+	if len(times) > 2 {
+		return nil, fmt.Errorf("bad time line: %q\n", lines[1])
+	}
+	summary := lines[2]
+	ev := &api.Event{Summary: summary}
+	var descLines []string
+	for _, line := range lines[3:] {
+		ok, err := setField(ev, line)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %v", summary, err)
+		}
+		if !ok {
+			descLines = append(descLines, line)
+		}
+	}
+	desc := strings.Join(descLines, "\n")
+	if *sanitizeDesc {
+		var err error
+		if desc, err = sanitizeHTML(desc); err != nil {
+			return nil, fmt.Errorf("%q: %v", summary, err)
+		}
+	}
+
+	start, err := parseTime(date + " " + times[0])
+	if err != nil {
+		return nil, err
+	}
+	// A single time is the start; the event lasts for -default-duration.
+	end := start.Add(*defaultDuration)
+	if len(times) == 2 {
+		end, err = parseTime(date + " " + times[1])
+		if err != nil {
+			return nil, err
+		}
+		if !end.After(start) {
+			return nil, fmt.Errorf("%q: end time %s is not after start time %s", summary, times[1], times[0])
+		}
+	}
+	if err := validateText(summary, desc); err != nil {
+		return nil, err
+	}
+	ev.Start = eventDateTime(start)
+	ev.End = eventDateTime(end)
+	ev.Description = desc
+	return ev, nil
+}
+
+// Limits on event fields enforced by Google Calendar. Exceeding them
+// results in a 400 from the API, so we check them up front.
+const (
+	maxSummaryLen     = 1024
+	maxDescriptionLen = 8192
+)
+
+func validateText(summary, desc string) error {
+	if err := validateSummary(summary); err != nil {
+		return err
+	}
+	return validateDescription(summary, desc)
+}
+
+func validateSummary(summary string) error {
+	if summary == "" {
+		return fmt.Errorf("empty summary")
+	}
+	if n := utf8.RuneCountInString(summary); n > maxSummaryLen {
+		return fmt.Errorf("%.40q...: summary is %d characters; the limit is %d", summary, n, maxSummaryLen)
+	}
+	return nil
+}
+
+// validateDescription checks the description of the event with summary.
+func validateDescription(summary, desc string) error {
+	if n := utf8.RuneCountInString(desc); n > maxDescriptionLen && !*longDescDoc {
+		return fmt.Errorf("%q: description is %d characters; the limit is %d", summary, n, maxDescriptionLen)
+	}
+	return nil
+}
+
+// writeEventFile writes evs to filename in the format that readEventFile
+// reads for its extension: JSON, JSON Lines, iCalendar, or otherwise the
+// text format. See checkWritable for the extensions it can't write.
+func writeEventFile(filename string, evs []*api.Event) error {
+	if err := checkWritable(filename); err != nil {
+		return err
+	}
+	switch ext := filepath.Ext(filename); ext {
+	case ".json", ".jsonl", ".ndjson", ".ics", ".ical":
+		var ces []*calendar.Event
+		for _, ev := range evs {
+			ce, err := calendar.FromAPI(ev)
+			if err != nil {
+				return err
+			}
+			ce.ID = ""
+			ces = append(ces, ce)
+		}
+		var buf bytes.Buffer
+		switch ext {
+		case ".json":
+			data, err := json.MarshalIndent(ces, "", "  ")
+			if err != nil {
+				return err
+			}
+			buf.Write(data)
+			buf.WriteByte('\n')
+		case ".jsonl", ".ndjson":
+			enc := json.NewEncoder(&buf)
+			for _, ce := range ces {
+				if err := enc.Encode(ce); err != nil {
+					return err
+				}
+			}
+		default:
+			if err := calendar.WriteICS(&buf, ces); err != nil {
+				return err
+			}
+		}
+		return ioutil.WriteFile(filename, buf.Bytes(), 0644)
+	}
+	var blocks []string
+	for _, ev := range evs {
+		b, err := formatEvent(ev)
+		if err != nil {
+			return err
+		}
+		blocks = append(blocks, b)
+	}
+	return ioutil.WriteFile(filename, []byte(strings.Join(blocks, "\n\n")+"\n"), 0644)
+}
+
+// checkWritable returns an error if writeEventFile can't write a file
+// with filename's extension in a format that readEventFile reads back.
+func checkWritable(filename string) error {
+	switch ext := filepath.Ext(filename); ext {
+	case ".jsonld", ".md", ".markdown", ".yaml", ".yml", ".rem", ".remind", ".csv":
+		return fmt.Errorf("%s: can't write %s files; use .json, .jsonl, .ics, or .txt for the text format", filename, ext)
+	}
+	return nil
+}
+
+func formatEvent(ev *api.Event) (string, error) {
+	if ev.Start.DateTime == "" {
+		return "", fmt.Errorf("%q: can't write an all-day event in the events file format", ev.Summary)
+	}
+	start, err := parseDateTime(ev.Start)
+	if err != nil {
+		return "", err
+	}
+	end, err := parseDateTime(ev.End)
+	if err != nil {
+		return "", err
+	}
+	// readEventFile reads times in inputLoc.
+	start, end = start.In(inputLoc), end.In(inputLoc)
+	for _, t := range []time.Time{start, end} {
+		if inRepeatedHour(t) {
+			return "", fmt.Errorf("%q: %s is in the hour repeated at the end of daylight saving time in %s; write a .ics or .json file instead",
+				ev.Summary, t.Format(time.RFC3339), inputLoc)
+		}
+	}
+	lines := []string{
+		start.Format("2006 January 2"),
+		start.Format("3:04pm") + " - " + end.Format("3:04pm"),
+		ev.Summary,
+	}
+	lines = append(lines, formatFields(ev)...)
+	if ev.Description != "" {
+		lines = append(lines, ev.Description)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// inRepeatedHour reports whether t's wall clock time comes around twice
+// in its location, and t is the second time. Wall clock times read back
+// as the first.
+func inRepeatedHour(t time.Time) bool {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	return !wall.Equal(t)
+}
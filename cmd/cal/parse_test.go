@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// benchFormats describe how to write events in each input format.
+var benchFormats = []struct {
+	ext    string
+	header string
+	event  func(i int, t time.Time) string
+	sep    string
+}{
+	{".txt", "", func(i int, t time.Time) string {
+		return fmt.Sprintf("%s\n7:00pm – 9:00pm\nEvent %d\nstatus: tentative\nBring the scores.", t.Format("2006 January 2"), i)
+	}, "\n\n"},
+	{".json", "[", func(i int, t time.Time) string {
+		return fmt.Sprintf(`{"summary": "Event %d", "start": %q, "end": %q, "description": "Bring the scores."}`,
+			i, t.Add(19*time.Hour).Format(time.RFC3339), t.Add(21*time.Hour).Format(time.RFC3339))
+	}, ",\n"},
+	{".jsonl", "", func(i int, t time.Time) string {
+		return fmt.Sprintf(`{"summary": "Event %d", "start": %q, "end": %q}`,
+			i, t.Add(19*time.Hour).Format(time.RFC3339), t.Add(21*time.Hour).Format(time.RFC3339))
+	}, "\n"},
+	{".jsonld", "[", func(i int, t time.Time) string {
+		return fmt.Sprintf(`{"@type": "Event", "name": "Event %d", "startDate": %q, "endDate": %q}`,
+			i, t.Add(19*time.Hour).Format(time.RFC3339), t.Add(21*time.Hour).Format(time.RFC3339))
+	}, ",\n"},
+	{".yaml", "", func(i int, t time.Time) string {
+		return fmt.Sprintf("- date: %s\n  start: 7pm\n  end: 9pm\n  summary: Event %d\n  description: Bring the scores.", t.Format("2006 January 2"), i)
+	}, "\n"},
+	{".md", "", func(i int, t time.Time) string {
+		return fmt.Sprintf("# %s\n\n- 7:00pm–9:00pm Event %d — bring the scores\n  - and the stands", t.Format("2006 January 2"), i)
+	}, "\n\n"},
+	{".csv", "", func(i int, t time.Time) string {
+		return fmt.Sprintf("%s,7:00pm,9:00pm,Event %d,Bring the scores.", t.Format("2006 January 2"), i)
+	}, "\n"},
+	{".ics", "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n", func(i int, t time.Time) string {
+		return fmt.Sprintf("BEGIN:VEVENT\r\nUID:%d@example.com\r\nDTSTART:%s\r\nDTEND:%s\r\nSUMMARY:Event %d\r\nDESCRIPTION:Bring the scores.\r\nEND:VEVENT",
+			i, t.Add(19*time.Hour).UTC().Format("20060102T150405Z"), t.Add(21*time.Hour).UTC().Format("20060102T150405Z"), i)
+	}, "\r\n"},
+	{".rem", "", func(i int, t time.Time) string {
+		return fmt.Sprintf("REM %s AT 19:00 DURATION 2:00 MSG Event %d", t.Format("2 Jan 2006"), i)
+	}, "\n"},
+}
+
+// writeBenchFiles writes a file of n events in each format to dir, and
+// returns their names.
+func writeBenchFiles(tb testing.TB, dir string, n int) []string {
+	if err := setGrammars("long,iso"); err != nil {
+		tb.Fatal(err)
+	}
+	day := time.Date(2026, 1, 1, 0, 0, 0, 0, inputLoc)
+	var names []string
+	for _, f := range benchFormats {
+		var b strings.Builder
+		b.WriteString(f.header)
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				b.WriteString(f.sep)
+			}
+			// One event a day, so that Markdown headings stay dates.
+			b.WriteString(f.event(i, day.AddDate(0, 0, i)))
+		}
+		switch f.ext {
+		case ".json", ".jsonld":
+			b.WriteString("]")
+		case ".ics":
+			b.WriteString("\r\nEND:VCALENDAR\r\n")
+		}
+		name := filepath.Join(dir, "events"+f.ext)
+		if err := os.WriteFile(name, []byte(b.String()), 0644); err != nil {
+			tb.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func BenchmarkReadEventFile(b *testing.B) {
+	const n = 10000
+	for _, name := range writeBenchFiles(b, b.TempDir(), n) {
+		b.Run(strings.TrimPrefix(filepath.Ext(name), "."), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				evs, err := readEventFile(name)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(evs) != n {
+					b.Fatalf("got %d events, want %d", len(evs), n)
+				}
+			}
+		})
+	}
+}
+
+// allocBudgets are the most allocations per event that reading each
+// format may take. They are about a third above what it takes now, so
+// they catch a hot path that starts allocating per line or per file.
+var allocBudgets = map[string]float64{
+	".txt":    13,
+	".json":   36,
+	".jsonl":  15,
+	".jsonld": 42,
+	".yaml":   115,
+	".md":     21,
+	".csv":    15,
+	".ics":    40,
+	".rem":    80,
+}
+
+func TestReadEventFileAllocs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("slow")
+	}
+	const n = 1000
+	for _, name := range writeBenchFiles(t, t.TempDir(), n) {
+		ext := filepath.Ext(name)
+		allocs := testing.AllocsPerRun(3, func() {
+			if _, err := readEventFile(name); err != nil {
+				t.Fatal(err)
+			}
+		}) / n
+		t.Logf("%s: %.1f allocations per event", ext, allocs)
+		if allocs > allocBudgets[ext] {
+			t.Errorf("%s: %.1f allocations per event, over the budget of %.0f", ext, allocs, allocBudgets[ext])
+		}
+	}
+}
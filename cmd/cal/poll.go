@@ -0,0 +1,358 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jba/calendar"
+	api "google.golang.org/api/calendar/v3"
+)
+
+var (
+	pollFlags    = flag.NewFlagSet("poll", flag.ExitOnError)
+	pollFile     = pollFlags.String("file", "", "poll export: a .csv grid of slots and votes, or a .ics of the chosen slots")
+	pollSummary  = pollFlags.String("summary", "", "summary of the event (default the poll's title)")
+	pollLocation = pollFlags.String("location", "", "location of the event")
+	pollWinners  = pollFlags.Int("winners", 1, "number of slots to schedule, the most popular first")
+	pollPeople   = pollFlags.String("people", "", "emails of participants, as Ann=ann@example.com,Bob=bob@example.com")
+	pollNotify   = pollFlags.String("notify", "all", "whom to email invitations: all, externalOnly or none")
+	pollDoit     = pollFlags.Bool("doit", false, "nothing is changed unless this is provided")
+)
+
+// A pollSlot is a column of a poll: a time and the participants' votes.
+type pollSlot struct {
+	label      string
+	start, end time.Time
+	allDay     bool
+	yes, maybe int
+}
+
+// runPoll finishes a scheduling poll: it picks the winning slots of a
+// Doodle-style export and inserts an event for each, with every
+// participant as an attendee.
+func runPoll(ctx context.Context, args []string) {
+	if *pollFile == "" {
+		log.Fatal("need -file")
+	}
+	switch *pollNotify {
+	case "all", "externalOnly", "none":
+	default:
+		log.Fatalf("-notify: want all, externalOnly or none, got %q", *pollNotify)
+	}
+	data, err := readSource(*pollFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var title string
+	var people []string
+	var evs []*api.Event
+	if ext := sourceExt(*pollFile); ext == ".ics" || ext == ".ical" {
+		// The poll was closed, and the export holds only the chosen slots.
+		if evs, err = readICS(data); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		var slots []pollSlot
+		title, people, slots, err = readPoll(data)
+		if err != nil {
+			log.Fatalf("%s: %v", *pollFile, err)
+		}
+		sort.SliceStable(slots, func(i, j int) bool {
+			if slots[i].yes != slots[j].yes {
+				return slots[i].yes > slots[j].yes
+			}
+			return slots[i].maybe > slots[j].maybe
+		})
+		for i, s := range slots {
+			info("%d yes, %d if need be\t%s\n", s.yes, s.maybe, s.label)
+			if i >= *pollWinners {
+				continue
+			}
+			ev := &api.Event{Start: eventDateTime(s.start), End: eventDateTime(s.end)}
+			if s.allDay {
+				ev.Start = &api.EventDateTime{Date: s.start.Format(time.DateOnly)}
+				ev.End = &api.EventDateTime{Date: s.end.Format(time.DateOnly)}
+			}
+			evs = append(evs, ev)
+		}
+		sort.Slice(evs, func(i, j int) bool {
+			return evs[i].Start.DateTime+evs[i].Start.Date < evs[j].Start.DateTime+evs[j].Start.Date
+		})
+	}
+	attendees, err := pollAttendees(people, *pollPeople)
+	if err != nil {
+		log.Fatal(err)
+	}
+	summary := cmp.Or(*pollSummary, title, "Meeting")
+	for _, ev := range evs {
+		if *pollSummary != "" || ev.Summary == "" {
+			ev.Summary = summary
+		}
+		if *pollLocation != "" {
+			ev.Location = *pollLocation
+		}
+		ev.Attendees = append(ev.Attendees, attendees...)
+	}
+	if !*pollDoit {
+		for _, ev := range evs {
+			if jsonOut {
+				emitJSON(newEventRecord("would insert", ev))
+				continue
+			}
+			fmt.Printf("would insert\t%s - %s\t%q\t%d attendees\n", eventTime(ev.Start), eventTime(ev.End), ev.Summary, len(ev.Attendees))
+		}
+		info("provide -doit to insert\n")
+		return
+	}
+	client := newClient(ctx)
+	client.Use(sendUpdates(*pollNotify))
+	for _, ev := range evs {
+		iev, err := client.InsertEvent(ctx, calID, ev)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if jsonOut {
+			emitJSON(newEventRecord("inserted", iev))
+			continue
+		}
+		fmt.Printf("inserted %s\t%s - %s\t%q\t%d attendees\n", iev.Id, eventTime(iev.Start), eventTime(iev.End), iev.Summary, len(iev.Attendees))
+	}
+}
+
+// readPoll reads a poll exported as CSV by Doodle, or in the same layout:
+// a preamble whose first row is the title, header rows that describe
+// each column's slot (such as a month row, a day row and a time row,
+// where a month applies to the columns after it until the next), then
+// a row of votes for each participant, which may be followed by a count
+// row. A vote is OK or Yes, or (OK) for "if need be".
+func readPoll(data []byte) (title string, people []string, slots []pollSlot, err error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	rows, err := r.ReadAll()
+	if err != nil {
+		return "", nil, nil, err
+	}
+	i := 0
+	for ; i < len(rows) && strings.TrimSpace(rows[i][0]) != ""; i++ {
+		if i == 0 {
+			title = strings.TrimSpace(rows[i][0])
+			title = strings.Trim(strings.TrimPrefix(title, "Poll "), `"`)
+		}
+	}
+	var header [][]string
+	for ; i < len(rows) && strings.TrimSpace(rows[i][0]) == ""; i++ {
+		if strings.Join(rows[i], "") != "" {
+			header = append(header, rows[i])
+		}
+	}
+	if len(header) == 0 {
+		return "", nil, nil, fmt.Errorf("no header rows describing the slots")
+	}
+	// Each slot's label joins its header cells. Blank cells in all but the
+	// last header row repeat the cell before them.
+	ncols := 0
+	for _, h := range header {
+		ncols = max(ncols, len(h))
+	}
+	for c := 1; c < ncols; c++ {
+		var parts []string
+		for k, h := range header {
+			cell := ""
+			if c < len(h) {
+				cell = strings.TrimSpace(h[c])
+			}
+			for j := min(c, len(h)) - 1; cell == "" && k < len(header)-1 && j >= 1; j-- {
+				cell = strings.TrimSpace(h[j])
+			}
+			if cell != "" {
+				parts = append(parts, cell)
+			}
+		}
+		label := strings.Join(parts, " ")
+		if label == "" {
+			continue
+		}
+		s, err := parsePollSlot(label)
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("column %d: %v", c+1, err)
+		}
+		for _, row := range rows[i:] {
+			name := strings.TrimSpace(row[0])
+			if name == "" || strings.EqualFold(name, "count") || c >= len(row) {
+				continue
+			}
+			switch strings.ToLower(strings.TrimSpace(row[c])) {
+			case "ok", "yes", "y", "1", "✓", "✔":
+				s.yes++
+			case "(ok)", "if need be", "ifneedbe", "maybe":
+				s.maybe++
+			}
+		}
+		slots = append(slots, s)
+	}
+	for _, row := range rows[i:] {
+		if name := strings.TrimSpace(row[0]); name != "" && !strings.EqualFold(name, "count") {
+			people = append(people, name)
+		}
+	}
+	if len(slots) == 0 {
+		return "", nil, nil, fmt.Errorf("no slots")
+	}
+	return title, people, slots, nil
+}
+
+var (
+	pollDateRE  = regexp.MustCompile(`\b(\d{4})-(\d{2})-(\d{2})\b`)
+	pollMonthRE = regexp.MustCompile(`(?i)\b(jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)[a-z]*\.?`)
+	pollYearRE  = regexp.MustCompile(`\b\d{4}\b`)
+	pollDayRE   = regexp.MustCompile(`\b\d{1,2}\b`)
+	pollTimesRE = regexp.MustCompile(`(?i)(\d{1,2}(?::\d{2})?\s*(?:[ap]\.?m\.?)?)\s*(?:-|–|to)\s*(\d{1,2}(?::\d{2})?\s*(?:[ap]\.?m\.?)?)`)
+)
+
+// parsePollSlot parses the label of a poll column, like "October 2026
+// Tue 20 12:00 PM - 1:00 PM" or "2026-10-20 12:00-13:00", in inputLoc.
+// A label with no times is an all-day slot.
+func parsePollSlot(label string) (pollSlot, error) {
+	s := pollSlot{label: label}
+	rest := label
+	var times []string
+	if m := pollTimesRE.FindStringSubmatch(rest); m != nil {
+		times = m[1:]
+		rest = strings.Replace(rest, m[0], " ", 1)
+	}
+	var date time.Time
+	if m := pollDateRE.FindString(rest); m != "" {
+		var err error
+		if date, err = time.ParseInLocation(time.DateOnly, m, inputLoc); err != nil {
+			return s, err
+		}
+	} else {
+		month := pollMonthRE.FindStringSubmatch(rest)
+		year := pollYearRE.FindString(rest)
+		if month == nil || year == "" {
+			return s, fmt.Errorf("%q: no month and year", label)
+		}
+		rest = strings.Replace(strings.Replace(rest, month[0], " ", 1), year, " ", 1)
+		day := pollDayRE.FindString(rest)
+		if day == "" {
+			return s, fmt.Errorf("%q: no day", label)
+		}
+		var err error
+		date, err = time.ParseInLocation("Jan 2 2006", month[1]+" "+day+" "+year, inputLoc)
+		if err != nil {
+			return s, fmt.Errorf("%q: %v", label, err)
+		}
+	}
+	if times == nil {
+		s.allDay = true
+		s.start, s.end = date, date.AddDate(0, 0, 1)
+		return s, nil
+	}
+	start, err1 := parseClock(date, times[0], times[1])
+	end, err2 := parseClock(date, times[1], times[1])
+	if err1 != nil || err2 != nil {
+		return s, fmt.Errorf("%q: bad times", label)
+	}
+	if !end.After(start) {
+		end = end.AddDate(0, 0, 1)
+	}
+	s.start, s.end = start, end
+	return s, nil
+}
+
+var clockSpace = strings.NewReplacer(" ", "", ".", "")
+
+// parseClock returns the time t, like 7pm or 19:30, on date. If t has
+// no am or pm, it takes that of other, and without either it is on a
+// 24-hour clock.
+func parseClock(date time.Time, t, other string) (time.Time, error) {
+	norm := func(s string) string {
+		return strings.ToLower(clockSpace.Replace(s))
+	}
+	t, other = norm(t), norm(other)
+	if !strings.HasSuffix(t, "m") && strings.HasSuffix(other, "m") {
+		t += other[len(other)-2:]
+	}
+	if !strings.Contains(t, ":") {
+		if n := strings.IndexAny(t, "ap"); n >= 0 {
+			t = t[:n] + ":00" + t[n:]
+		} else {
+			t += ":00"
+		}
+	}
+	layout := "15:04"
+	if strings.HasSuffix(t, "m") {
+		layout = "3:04pm"
+	}
+	c, err := time.Parse(layout, t)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), c.Hour(), c.Minute(), 0, 0, inputLoc), nil
+}
+
+// pollAttendees returns the participants as attendees, finding their
+// emails in people, a list of name=email pairs. A participant whose name
+// is an email needs no pair. Any pairs not naming a participant add more
+// attendees, so for a .ics export people can list them all.
+func pollAttendees(participants []string, people string) ([]*api.EventAttendee, error) {
+	emails := map[string]string{}
+	var names []string
+	if people != "" {
+		for _, p := range strings.Split(people, ",") {
+			name, email, ok := strings.Cut(p, "=")
+			name, email = strings.TrimSpace(name), strings.TrimSpace(email)
+			if !ok || !strings.Contains(email, "@") {
+				return nil, fmt.Errorf("-people: bad pair %q: want name=email", p)
+			}
+			emails[strings.ToLower(name)] = email
+			names = append(names, name)
+		}
+	}
+	var as []*api.EventAttendee
+	var missing []string
+	seen := map[string]bool{}
+	add := func(name string) {
+		email, ok := emails[strings.ToLower(name)]
+		if !ok && strings.Contains(name, "@") {
+			email, name, ok = name, "", true
+		}
+		if !ok {
+			missing = append(missing, name)
+			return
+		}
+		if !seen[email] {
+			seen[email] = true
+			as = append(as, &api.EventAttendee{Email: email, DisplayName: name})
+		}
+	}
+	for _, p := range participants {
+		add(p)
+	}
+	for _, n := range names {
+		add(n)
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("no email for %s; give them with -people", strings.Join(missing, ", "))
+	}
+	return as, nil
+}
+
+// sendUpdates returns middleware that has the server email the attendees
+// of the events inserted as who says, as for -notify.
+func sendUpdates(who string) calendar.Middleware {
+	return func(ctx context.Context, call *calendar.Call, next func(context.Context) error) error {
+		call.SendUpdates = who
+		return next(ctx)
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+)
+
+var quickaddFlags = flag.NewFlagSet("quickadd", flag.ExitOnError)
+
+// runQuickAdd creates an event from a description like "Lunch with Ann
+// Friday at noon", which the server parses. There is no -doit, because
+// there is nothing to preview before the server has done the parsing.
+func runQuickAdd(ctx context.Context, args []string) {
+	text := strings.TrimSpace(strings.Join(args, " "))
+	if text == "" {
+		log.Fatal("need the text of the event")
+	}
+	ev, err := newService(ctx).Events.QuickAdd(calID, text).Context(ctx).Do()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if jsonOut {
+		emitJSON(newEventRecord("added", ev))
+		return
+	}
+	fmt.Printf("added %s\t%s\t%q\n", ev.Id, eventTime(ev.Start), ev.Summary)
+}
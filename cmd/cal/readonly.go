@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// readOnlyTransport fails every request that isn't a GET or HEAD, so
+// that nothing can be changed no matter what the caller does.
+type readOnlyTransport struct {
+	base http.RoundTripper
+}
+
+func (t readOnlyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, fmt.Errorf("-read-only: refusing %s %s", req.Method, req.URL.Redacted())
+	}
+	return t.base.RoundTrip(req)
+}
+
+// guard returns hc, wrapped to allow only reads if -read-only is set.
+func guard(hc *http.Client) *http.Client {
+	if !readOnly {
+		return hc
+	}
+	base := hc.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	c := *hc
+	c.Transport = readOnlyTransport{base}
+	return &c
+}
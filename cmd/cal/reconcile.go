@@ -0,0 +1,305 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jba/calendar"
+	api "google.golang.org/api/calendar/v3"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	reconcileFlags = flag.NewFlagSet("reconcile", flag.ExitOnError)
+	reconcileFile  = reconcileFlags.String("file", "commitments.yaml", "file of standing weekly commitments")
+	reconcileDoit  = reconcileFlags.Bool("doit", false, "nothing is changed unless this is provided")
+)
+
+// A commitmentsFile describes the weekly recurring events that cal
+// reconcile keeps on a calendar:
+//
+//	from: 2026-09-01
+//	until: 2026-12-18
+//	commitments:
+//	  - summary: Gym
+//	    days: [mon, wed, fri]
+//	    start: "7:00"
+//	    end: "8:00"
+//	  - name: algebra
+//	    summary: Algebra lecture
+//	    days: [tue, thu]
+//	    start: 10am
+//	    end: 11:15am
+//	    location: Room 101
+//
+// A commitment is identified by its name, or its summary if it has no name,
+// so a named one can be renamed without being recreated. From and until
+// can be set for each commitment; until is optional.
+type commitmentsFile struct {
+	From        string       `yaml:"from"`
+	Until       string       `yaml:"until"`
+	Commitments []commitment `yaml:"commitments"`
+}
+
+type commitment struct {
+	Name        string   `yaml:"name"`
+	Summary     string   `yaml:"summary"`
+	Description string   `yaml:"description"`
+	Location    string   `yaml:"location"`
+	Days        []string `yaml:"days"`
+	Start       string   `yaml:"start"`
+	End         string   `yaml:"end"`
+	From        string   `yaml:"from"`
+	Until       string   `yaml:"until"`
+}
+
+func (c commitment) key() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.Summary
+}
+
+// Private extended properties that mark the events cal reconcile manages.
+const (
+	reconcileProp  = "cal-reconcile"
+	commitmentProp = "cal-commitment"
+)
+
+// runReconcile makes the calendar's managed events match the commitments
+// file: it creates an event for each new commitment, changes those whose
+// commitment changed, and deletes those whose commitment was removed.
+// Events it didn't create are left alone.
+func runReconcile(ctx context.Context, args []string) {
+	data, err := os.ReadFile(*reconcileFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var cf commitmentsFile
+	if err := yaml.Unmarshal(data, &cf); err != nil {
+		log.Fatalf("%s: %v", *reconcileFile, err)
+	}
+	zone, err := inputZoneName()
+	if err != nil {
+		log.Fatal(err)
+	}
+	want := map[string]*api.Event{}
+	var order []string
+	for i, c := range cf.Commitments {
+		ev, err := commitmentEvent(c, cf, zone)
+		if err != nil {
+			log.Fatalf("%s: commitment %d: %v", *reconcileFile, i+1, err)
+		}
+		if _, ok := want[c.key()]; ok {
+			log.Fatalf("%s: two commitments are named %q", *reconcileFile, c.key())
+		}
+		want[c.key()] = ev
+		order = append(order, c.key())
+	}
+
+	client := newClient(ctx)
+	have := map[string]*api.Event{}
+	var extra []*api.Event // duplicates, to delete
+	err = client.Service().Events.List(calID).Context(ctx).
+		PrivateExtendedProperty(reconcileProp+"=1").
+		Pages(ctx, func(evs *api.Events) error {
+			for _, ev := range evs.Items {
+				if ev.ExtendedProperties == nil {
+					continue
+				}
+				k := ev.ExtendedProperties.Private[commitmentProp]
+				if _, ok := have[k]; ok {
+					extra = append(extra, ev)
+					continue
+				}
+				have[k] = ev
+			}
+			return nil
+		})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	failed := 0
+	report := func(action string, ev *api.Event, err error) {
+		if err != nil {
+			failed++
+			action = "failed to " + action
+		} else if !*reconcileDoit {
+			action = "would " + action
+		} else {
+			action += "d"
+		}
+		if jsonOut {
+			r := newEventRecord(action, ev)
+			if err != nil {
+				r.Error = err.Error()
+			}
+			emitJSON(r)
+			return
+		}
+		if err != nil {
+			log.Printf("%s %q: %v", action, ev.Summary, err)
+			return
+		}
+		fmt.Printf("%s\t%s\t%q\n", action, eventTime(ev.Start), ev.Summary)
+	}
+	changes := 0
+	for _, k := range order {
+		ev, old := want[k], have[k]
+		var err error
+		switch {
+		case old == nil:
+			changes++
+			if *reconcileDoit {
+				_, err = client.InsertEvent(ctx, calID, ev)
+			}
+			report("create", ev, err)
+		case !sameCommitment(old, ev):
+			changes++
+			if *reconcileDoit {
+				_, err = client.PatchEvent(ctx, calID, old.Id, ev)
+			}
+			report("update", ev, err)
+		}
+	}
+	for k, old := range have {
+		if _, ok := want[k]; !ok {
+			extra = append(extra, old)
+		}
+	}
+	for _, old := range extra {
+		var err error
+		changes++
+		if *reconcileDoit {
+			err = client.DeleteEvent(ctx, calID, old.Id)
+		}
+		report("delete", old, err)
+	}
+	switch {
+	case changes == 0:
+		info("calendar matches %s\n", *reconcileFile)
+	case !*reconcileDoit:
+		info("provide -doit to make %d changes\n", changes)
+	case failed > 0:
+		os.Exit(1)
+	}
+}
+
+// commitmentEvent returns the recurring event for c. Times are wall times
+// in zone, so the event keeps its time of day across DST changes.
+func commitmentEvent(c commitment, cf commitmentsFile, zone string) (*api.Event, error) {
+	if c.Summary == "" {
+		return nil, fmt.Errorf("no summary")
+	}
+	if len(c.Days) == 0 {
+		return nil, fmt.Errorf("%q: no days", c.Summary)
+	}
+	var byDay []string
+	var weekdays []time.Weekday
+	for _, d := range c.Days {
+		wd, ok := parseWeekday(d)
+		if !ok {
+			return nil, fmt.Errorf("%q: bad day %q", c.Summary, d)
+		}
+		weekdays = append(weekdays, wd)
+		byDay = append(byDay, strings.ToUpper(wd.String()[:2]))
+	}
+	from := cmp.Or(c.From, cf.From)
+	if from == "" {
+		return nil, fmt.Errorf("%q: no from date", c.Summary)
+	}
+	first, err := time.ParseInLocation(time.DateOnly, from, inputLoc)
+	if err != nil {
+		return nil, fmt.Errorf("%q: from: %v", c.Summary, err)
+	}
+	// The series starts on the first of its days on or after from.
+	for !slices.Contains(weekdays, first.Weekday()) {
+		first = first.AddDate(0, 0, 1)
+	}
+	start, err := timeOnDay(first, c.Start)
+	if err != nil {
+		return nil, fmt.Errorf("%q: start: %v", c.Summary, err)
+	}
+	end, err := timeOnDay(first, c.End)
+	if err != nil {
+		return nil, fmt.Errorf("%q: end: %v", c.Summary, err)
+	}
+	if !end.After(start) {
+		return nil, fmt.Errorf("%q: end %s is not after start %s", c.Summary, c.End, c.Start)
+	}
+	rule := calendar.Rule{Freq: "WEEKLY", ByDay: byDay}
+	if until := cmp.Or(c.Until, cf.Until); until != "" {
+		u, err := time.ParseInLocation(time.DateOnly, until, inputLoc)
+		if err != nil {
+			return nil, fmt.Errorf("%q: until: %v", c.Summary, err)
+		}
+		rule.Until = u.AddDate(0, 0, 1).Add(-time.Second)
+	}
+	ev := &api.Event{
+		Summary:     c.Summary,
+		Description: c.Description,
+		Location:    c.Location,
+		Start:       &api.EventDateTime{DateTime: start.Format(wallLayout), TimeZone: zone},
+		End:         &api.EventDateTime{DateTime: end.Format(wallLayout), TimeZone: zone},
+		Recurrence:  []string{"RRULE:" + rule.String()},
+		ExtendedProperties: &api.EventExtendedProperties{
+			Private: map[string]string{reconcileProp: "1", commitmentProp: c.key()},
+		},
+		// So that a patch clears them when they are removed from the file.
+		ForceSendFields: []string{"Description", "Location"},
+	}
+	return ev, nil
+}
+
+// timeOnDay returns the time of day s, like 17:30 or 5:30pm, on day.
+func timeOnDay(day time.Time, s string) (time.Time, error) {
+	for _, layout := range []string{"15:04", "3pm", "3:04pm"} {
+		if t, err := time.Parse(layout, strings.ToLower(strings.TrimSpace(s))); err == nil {
+			return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, day.Location()), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("bad time of day %q", s)
+}
+
+// parseWeekday parses a day name like Monday or mon.
+func parseWeekday(s string) (time.Weekday, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if len(s) < 2 {
+		return 0, false
+	}
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.HasPrefix(strings.ToLower(d.String()), s) {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// sameCommitment reports whether the event old, from the server, is the
+// one the commitment ev describes.
+func sameCommitment(old, ev *api.Event) bool {
+	if old.Summary != ev.Summary || old.Description != ev.Description || old.Location != ev.Location {
+		return false
+	}
+	if !slices.Equal(old.Recurrence, ev.Recurrence) {
+		return false
+	}
+	return sameTime(old.Start, ev.Start) && sameTime(old.End, ev.End)
+}
+
+func sameTime(a, b *api.EventDateTime) bool {
+	if a == nil || b == nil || a.TimeZone != b.TimeZone {
+		return false
+	}
+	ta, err1 := parseDateTime(a)
+	tb, err2 := parseDateTime(b)
+	return err1 == nil && err2 == nil && ta.Equal(tb)
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jba/calendar"
+	api "google.golang.org/api/calendar/v3"
+)
+
+// readRemind parses events from a reminder file of remind(1). It reads
+// the REM lines, with a date spec (any of day, month, year and weekdays,
+// or an ISO date), *n for a repeat every n days, AT, DURATION, FROM and
+// UNTIL or THROUGH, and a MSG, MSF or CAL body. Partial dates stand for
+// recurring events, as in remind: a day repeats monthly, a day and month
+// yearly, and weekdays weekly; a weekday and a day like 1, 8, 15 or 22
+// stand for the nth weekday of the month, or with a month, of the year.
+//
+// Omitted days are not applied. An OMIT clause in a REM line is ignored,
+// along with the SKIP, BEFORE or AFTER that would move the reminder off
+// an omitted day, so the event stays on that day. Advance warnings are
+// ignored too. Other commands, such as SET and global OMIT, have no
+// equivalent, and are skipped with a warning.
+func readRemind(data []byte) ([]*api.Event, error) {
+	var evs []*api.Event
+	lines := strings.Split(string(data), "\n")
+	for i := 0; i < len(lines); i++ {
+		n := i + 1
+		line := strings.TrimSpace(lines[i])
+		// A trailing backslash continues a line.
+		for strings.HasSuffix(line, `\`) && i+1 < len(lines) {
+			i++
+			line = strings.TrimSuffix(line, `\`) + " " + strings.TrimSpace(lines[i])
+		}
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		word, _, _ := strings.Cut(line, " ")
+		if !strings.EqualFold(word, "REM") {
+			slog.Warn(fmt.Sprintf("line %d: skipping %s", n, strings.ToUpper(word)))
+			continue
+		}
+		ev, err := remindEvent(strings.Fields(line)[1:])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		if ev != nil {
+			evs = append(evs, ev)
+		}
+	}
+	return evs, nil
+}
+
+// A remindDate is a date spec of a REM line. Its fields are zero when
+// missing.
+type remindDate struct {
+	day, year int
+	month     time.Month
+	weekdays  []time.Weekday
+}
+
+// parse consumes the tokens of a date spec at the start of toks, and
+// returns the rest.
+func (d *remindDate) parse(toks []string) []string {
+	for len(toks) > 0 {
+		t := toks[0]
+		if iso, ok := remindISODate(t); ok {
+			d.year, d.month, d.day = iso.Date()
+		} else if wd, ok := parseWeekday(t); ok && len(t) >= 3 {
+			d.weekdays = append(d.weekdays, wd)
+		} else if m, ok := remindMonth(t); ok {
+			d.month = m
+		} else if n, err := strconv.Atoi(t); err == nil && n >= 1 && n <= 31 {
+			d.day = n
+		} else if err == nil && n >= 1900 {
+			d.year = n
+		} else {
+			return toks
+		}
+		toks = toks[1:]
+	}
+	return toks
+}
+
+// remindISODate parses a date like 2026-10-20 or 2026/10/20.
+func remindISODate(s string) (time.Time, bool) {
+	if len(s) != len(time.DateOnly) {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.DateOnly, strings.ReplaceAll(s, "/", "-"))
+	return t, err == nil
+}
+
+func (d remindDate) full() bool { return d.day != 0 && d.month != 0 && d.year != 0 }
+
+func (d remindDate) time() time.Time {
+	return time.Date(d.year, d.month, d.day, 0, 0, 0, 0, inputLoc)
+}
+
+// remindMonth parses a month name like Oct or October.
+func remindMonth(s string) (time.Month, bool) {
+	s = strings.ToLower(s)
+	if len(s) < 3 {
+		return 0, false
+	}
+	for m := time.January; m <= time.December; m++ {
+		if strings.HasPrefix(strings.ToLower(m.String()), s) {
+			return m, true
+		}
+	}
+	return 0, false
+}
+
+// remindEvent makes an event from the tokens of a REM line after REM. It
+// returns nil for reminders that don't belong on a calendar, like RUN.
+func remindEvent(toks []string) (*api.Event, error) {
+	var (
+		date, from, until remindDate
+		at, dur           string
+		repeat            int
+		body              string
+	)
+	toks = date.parse(toks)
+loop:
+	for len(toks) > 0 {
+		t := strings.ToUpper(toks[0])
+		toks = toks[1:]
+		switch {
+		case t == "MSG" || t == "MSF" || t == "CAL":
+			body = strings.Join(toks, " ")
+			break loop
+		case t == "RUN" || t == "PS" || t == "PSFILE" || t == "SATISFY" || t == "SPECIAL":
+			return nil, nil
+		case t == "AT" && len(toks) > 0:
+			at, toks = toks[0], toks[1:]
+		case t == "DURATION" && len(toks) > 0:
+			dur, toks = toks[0], toks[1:]
+		case t == "FROM" || t == "SCANFROM":
+			toks = from.parse(toks)
+		case t == "UNTIL" || t == "THROUGH":
+			toks = until.parse(toks)
+		case t == "OMIT":
+			// Omitted weekdays, which are ignored; see readRemind.
+			var omit remindDate
+			toks = omit.parse(toks)
+		case t == "SCHED" || t == "WARN" || t == "TAG" || t == "PRIORITY" || t == "SKIP" || t == "BEFORE" || t == "AFTER" || t == "ONCE":
+			if t != "SKIP" && t != "BEFORE" && t != "AFTER" && t != "ONCE" && len(toks) > 0 {
+				toks = toks[1:]
+			}
+		case strings.HasPrefix(t, "*"):
+			var err error
+			if repeat, err = strconv.Atoi(t[1:]); err != nil || repeat < 1 {
+				return nil, fmt.Errorf("bad repeat %q", t)
+			}
+		case strings.HasPrefix(t, "+") || strings.HasPrefix(t, "-"):
+			// Advance warnings and back scans.
+		default:
+			// A date spec may also follow other clauses.
+			rest := date.parse(append([]string{t}, toks...))
+			if len(rest) == len(toks)+1 {
+				return nil, fmt.Errorf("unknown word %q", t)
+			}
+			toks = rest
+		}
+	}
+	summary := remindBody(body)
+	if summary == "" {
+		return nil, fmt.Errorf("no MSG")
+	}
+	if err := validateText(summary, ""); err != nil {
+		return nil, err
+	}
+
+	// Find the first occurrence, and the rule for the rest.
+	var rule *calendar.Rule
+	after := now().In(inputLoc)
+	after = time.Date(after.Year(), after.Month(), after.Day(), 0, 0, 0, 0, inputLoc)
+	if from.full() {
+		after = from.time()
+	}
+	var first time.Time
+	byMonth := ""
+	switch {
+	case date.full():
+		first = date.time()
+		for len(date.weekdays) > 0 && !slices.Contains(date.weekdays, first.Weekday()) {
+			first = first.AddDate(0, 0, 1)
+		}
+		if repeat > 0 {
+			rule = &calendar.Rule{Freq: "DAILY", Interval: repeat}
+		}
+	case date.day == 0 && date.month == 0 && date.year == 0 && len(date.weekdays) > 0:
+		var byDay []string
+		for _, wd := range date.weekdays {
+			byDay = append(byDay, strings.ToUpper(wd.String()[:2]))
+		}
+		first = after
+		for !slices.Contains(date.weekdays, first.Weekday()) {
+			first = first.AddDate(0, 0, 1)
+		}
+		rule = &calendar.Rule{Freq: "WEEKLY", ByDay: byDay}
+	case date.day != 0 && date.month == 0 && date.year == 0 && len(date.weekdays) == 0:
+		first = after
+		for first.Day() != date.day {
+			first = first.AddDate(0, 0, 1)
+		}
+		rule = &calendar.Rule{Freq: "MONTHLY"}
+	case date.day != 0 && date.month != 0 && date.year == 0 && len(date.weekdays) == 0:
+		first = time.Date(after.Year(), date.month, date.day, 0, 0, 0, 0, inputLoc)
+		if first.Before(after) {
+			first = first.AddDate(1, 0, 0)
+		}
+		rule = &calendar.Rule{Freq: "YEARLY"}
+	case date.year == 0 && len(date.weekdays) == 1 && (date.day-1)%7 == 0:
+		// The first weekday on or after the day of the month is its nth
+		// weekday of the month, as in Mon 1 Sep for the first Monday.
+		wd := date.weekdays[0]
+		nth := fmt.Sprintf("%d%s", (date.day-1)/7+1, strings.ToUpper(wd.String()[:2]))
+		rule = &calendar.Rule{Freq: "MONTHLY", ByDay: []string{nth}}
+		if date.month != 0 {
+			rule.Freq = "YEARLY"
+			byMonth = fmt.Sprintf(";BYMONTH=%d", date.month)
+		}
+		first = after
+		for first.Weekday() != wd || (first.Day()-1)/7 != (date.day-1)/7 || date.month != 0 && first.Month() != date.month {
+			first = first.AddDate(0, 0, 1)
+		}
+	default:
+		return nil, fmt.Errorf("%q: unsupported date spec", summary)
+	}
+	if rule == nil && until.full() {
+		return nil, fmt.Errorf("%q: UNTIL without a repeat", summary)
+	}
+	if rule != nil && until.full() {
+		rule.Until = until.time().AddDate(0, 0, 1).Add(-time.Second)
+	}
+
+	ev := &api.Event{Summary: summary}
+	if at == "" {
+		ev.Start = &api.EventDateTime{Date: first.Format(time.DateOnly)}
+		ev.End = &api.EventDateTime{Date: first.AddDate(0, 0, 1).Format(time.DateOnly)}
+	} else {
+		start, err := timeOnDay(first, at)
+		if err != nil {
+			return nil, fmt.Errorf("%q: AT: %v", summary, err)
+		}
+		end := start.Add(*defaultDuration)
+		if dur != "" {
+			d, err := remindDuration(dur)
+			if err != nil {
+				return nil, fmt.Errorf("%q: DURATION: %v", summary, err)
+			}
+			end = start.Add(d)
+		}
+		ev.Start, ev.End = eventDateTime(start), eventDateTime(end)
+		if rule != nil {
+			// Recurring events need a time zone, to repeat at the same
+			// wall time.
+			zone, err := inputZoneName()
+			if err != nil {
+				return nil, err
+			}
+			ev.Start = &api.EventDateTime{DateTime: start.Format(wallLayout), TimeZone: zone}
+			ev.End = &api.EventDateTime{DateTime: end.Format(wallLayout), TimeZone: zone}
+		}
+	}
+	if rule != nil {
+		// calendar.Rule has no BYMONTH, which only yearly weekday rules need.
+		ev.Recurrence = []string{"RRULE:" + rule.String() + byMonth}
+	}
+	return ev, nil
+}
+
+// remindDuration parses a DURATION, h:mm or a number of minutes.
+func remindDuration(s string) (time.Duration, error) {
+	h, m, ok := strings.Cut(s, ":")
+	if !ok {
+		h, m = "0", s
+	}
+	hn, err1 := strconv.Atoi(h)
+	mn, err2 := strconv.Atoi(m)
+	if err1 != nil || err2 != nil || hn < 0 || mn < 0 {
+		return 0, fmt.Errorf("bad duration %q", s)
+	}
+	return time.Duration(hn)*time.Hour + time.Duration(mn)*time.Minute, nil
+}
+
+// remindBody returns the text of a reminder's body, without its
+// substitution sequences. Text between %" markers is what remind shows
+// on its calendar, so only it is kept.
+func remindBody(body string) string {
+	if i := strings.Index(body, `%"`); i >= 0 {
+		rest := body[i+2:]
+		if j := strings.Index(rest, `%"`); j >= 0 {
+			body = rest[:j]
+		}
+	}
+	var b strings.Builder
+	for i := 0; i < len(body); i++ {
+		if body[i] != '%' {
+			b.WriteByte(body[i])
+			continue
+		}
+		if i+1 < len(body) && body[i+1] == '%' {
+			b.WriteByte('%')
+		}
+		i++
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
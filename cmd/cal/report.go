@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jba/calendar"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/option"
+)
+
+// sendReport emails a report of the run to the address to, sending it
+// from the account in the creds. The report covers each calendar in cals,
+// in order.
+func sendReport(ctx context.Context, to string, cals []string, results map[string]*insertResult) error {
+	creds, err := credsJSON()
+	if err != nil {
+		return err
+	}
+	hc, err := calendar.NewHTTPClient(ctx, calendar.WithCredentialsJSON(creds), calendar.WithScopes(gmail.GmailSendScope))
+	if err != nil {
+		return err
+	}
+	gm, err := gmail.NewService(ctx, option.WithHTTPClient(guard(hc)))
+	if err != nil {
+		return err
+	}
+	var ninserted, nfailed int
+	for _, r := range results {
+		ninserted += len(r.inserted)
+		nfailed += len(r.failed)
+	}
+	subject := fmt.Sprintf("cal: inserted %d events from %s", ninserted, *eventFile)
+	if nfailed > 0 {
+		subject += fmt.Sprintf(", %d failed", nfailed)
+	}
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(reportBody(cals, results))
+	_, err = gm.Users.Messages.Send("me", &gmail.Message{
+		Raw: base64.URLEncoding.EncodeToString([]byte(msg.String())),
+	}).Context(ctx).Do()
+	return err
+}
+
+func reportBody(cals []string, results map[string]*insertResult) string {
+	var b strings.Builder
+	for _, c := range cals {
+		r := results[c]
+		if r == nil {
+			continue
+		}
+		fmt.Fprintf(&b, "Calendar %s: %d inserted, %d failed\n\n", c, len(r.inserted), len(r.failed))
+		tw := tabwriter.NewWriter(&b, 0, 8, 2, ' ', 0)
+		for _, ev := range r.inserted {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", eventTime(ev.Start), ev.Summary, ev.HtmlLink)
+		}
+		tw.Flush()
+		if len(r.failed) > 0 {
+			fmt.Fprintf(&b, "\nFailed:\n")
+			tw = tabwriter.NewWriter(&b, 0, 8, 2, ' ', 0)
+			for i, ev := range r.failed {
+				fmt.Fprintf(tw, "%s\t%s\t%v\n", eventTime(ev.Start), ev.Summary, r.errs[i])
+			}
+			tw.Flush()
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
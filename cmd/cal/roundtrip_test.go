@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/csv"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	api "google.golang.org/api/calendar/v3"
+)
+
+// Zones for the round-trip tests: with and without daylight saving time,
+// south of the equator, and with a half-hour shift.
+var roundTripZones = []string{
+	"UTC",
+	"America/New_York",
+	"Europe/Berlin",
+	"Australia/Sydney",
+	"Australia/Lord_Howe",
+	"Asia/Kolkata",
+}
+
+// dstEdges returns the times in 2026 at which loc's offset changes.
+func dstEdges(loc *time.Location) []time.Time {
+	var edges []time.Time
+	t := time.Date(2026, 1, 1, 0, 0, 0, 0, loc)
+	_, off := t.Zone()
+	for ; t.Year() == 2026; t = t.Add(time.Hour) {
+		if _, o := t.Zone(); o != off {
+			edges = append(edges, t)
+			off = o
+		}
+	}
+	return edges
+}
+
+// randomEvent returns an event that the text, CSV, iCalendar and JSON
+// formats can all hold: minute precision, starting and ending on the
+// same day in loc, and with a summary and description of plain text.
+// Half of the events are within a few hours of a DST change.
+//
+// Some events start or end in the hour repeated when daylight saving
+// time ends. The formats that write wall clock times can't hold them;
+// see wallClock.
+func randomEvent(r *rand.Rand, loc *time.Location, edges []time.Time) *api.Event {
+	var start time.Time
+	for {
+		if len(edges) > 0 && r.Intn(2) == 0 {
+			edge := edges[r.Intn(len(edges))]
+			start = edge.Add(time.Duration(r.Intn(6*60)-3*60) * time.Minute)
+		} else {
+			start = time.Date(2026, 1, 1, 0, 0, 0, 0, loc).Add(time.Duration(r.Intn(365*24*60)) * time.Minute)
+		}
+		start = start.In(loc).Truncate(time.Minute)
+		end := start.Add(time.Duration(1+r.Intn(4*60)) * time.Minute)
+		if end.In(loc).YearDay() != start.YearDay() {
+			continue
+		}
+		return &api.Event{
+			Summary:     randomText(r, 1+r.Intn(6)),
+			Description: randomText(r, r.Intn(12)),
+			Start:       &api.EventDateTime{DateTime: start.Format(time.RFC3339)},
+			End:         &api.EventDateTime{DateTime: end.Format(time.RFC3339)},
+		}
+	}
+}
+
+var roundTripWords = []string{"rehearsal", "Zoë", "café", "a,b", "x;y", `back\slash`, "naïve", "日本語", "50%", "\"quoted\"", "(room 4)", "😀"}
+
+func randomText(r *rand.Rand, n int) string {
+	var ws []string
+	for i := 0; i < n; i++ {
+		ws = append(ws, roundTripWords[r.Intn(len(roundTripWords))])
+	}
+	return strings.Join(ws, " ")
+}
+
+// writeCSV writes evs in the default -map layout,
+// date=1,start=2,end=3,summary=4,desc=5.
+func writeCSV(filename string, evs []*api.Event) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	for _, ev := range evs {
+		start, _ := parseDateTime(ev.Start)
+		end, _ := parseDateTime(ev.End)
+		start, end = start.In(inputLoc), end.In(inputLoc)
+		w.Write([]string{start.Format("2006 January 2"), start.Format("3:04pm"), end.Format("3:04pm"), ev.Summary, ev.Description})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// TestRoundTrip writes random events in each format and reads them back,
+// in several zones, checking that nothing changes.
+func TestRoundTrip(t *testing.T) {
+	if err := setGrammars("long,iso"); err != nil {
+		t.Fatal(err)
+	}
+	defer func(loc *time.Location) { inputLoc = loc }(inputLoc)
+	formats := []struct {
+		ext   string
+		write func(string, []*api.Event) error
+		// wallClock formats write times without an offset, and are read
+		// in inputLoc.
+		wallClock bool
+	}{
+		{".txt", writeEventFile, true},
+		{".ics", writeEventFile, false},
+		{".json", writeEventFile, false},
+		{".jsonl", writeEventFile, false},
+		{".csv", writeCSV, true},
+	}
+	dir := t.TempDir()
+	for _, zone := range roundTripZones {
+		loc, err := time.LoadLocation(zone)
+		if err != nil {
+			t.Fatal(err)
+		}
+		inputLoc = loc
+		r := rand.New(rand.NewSource(1))
+		edges := dstEdges(loc)
+		var all, wall []*api.Event
+		for i := 0; i < 200; i++ {
+			ev := randomEvent(r, loc, edges)
+			all = append(all, ev)
+			if !eventInRepeatedHour(ev) {
+				wall = append(wall, ev)
+			} else if _, err := formatEvent(ev); err == nil {
+				t.Errorf("%s: formatEvent(%s - %s) succeeded, want an error for the repeated hour", zone, ev.Start.DateTime, ev.End.DateTime)
+			}
+		}
+		for _, f := range formats {
+			evs := all
+			if f.wallClock {
+				evs = wall
+			}
+			name := filepath.Join(dir, strings.ReplaceAll(zone, "/", "-")+f.ext)
+			if err := f.write(name, evs); err != nil {
+				t.Fatalf("%s %s: writing: %v", zone, f.ext, err)
+			}
+			got, err := readEventFile(name)
+			if err != nil {
+				t.Fatalf("%s %s: reading: %v", zone, f.ext, err)
+			}
+			if len(got) != len(evs) {
+				t.Fatalf("%s %s: got %d events, want %d", zone, f.ext, len(got), len(evs))
+			}
+			for i, want := range evs {
+				if diff := eventDiff(got[i], want); diff != "" {
+					t.Errorf("%s %s: event %d: %s", zone, f.ext, i, diff)
+				}
+			}
+		}
+	}
+}
+
+func eventInRepeatedHour(ev *api.Event) bool {
+	start, _ := parseDateTime(ev.Start)
+	end, _ := parseDateTime(ev.End)
+	return inRepeatedHour(start.In(inputLoc)) || inRepeatedHour(end.In(inputLoc))
+}
+
+// eventDiff describes how got differs from want, or returns "".
+func eventDiff(got, want *api.Event) string {
+	var diffs []string
+	if got.Summary != want.Summary {
+		diffs = append(diffs, "summary "+quote(got.Summary)+", want "+quote(want.Summary))
+	}
+	if got.Description != want.Description {
+		diffs = append(diffs, "description "+quote(got.Description)+", want "+quote(want.Description))
+	}
+	for _, p := range []struct {
+		name      string
+		got, want *api.EventDateTime
+	}{{"start", got.Start, want.Start}, {"end", got.End, want.End}} {
+		g, err1 := parseDateTime(p.got)
+		w, err2 := parseDateTime(p.want)
+		if err1 != nil || err2 != nil || !g.Equal(w) {
+			diffs = append(diffs, p.name+" "+p.got.DateTime+", want "+p.want.DateTime)
+		}
+	}
+	return strings.Join(diffs, "; ")
+}
+
+func quote(s string) string { return "\"" + s + "\"" }
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Tags that the Calendar UI renders in event descriptions.
+var allowedTags = map[string]bool{
+	"a": true, "b": true, "i": true, "u": true, "br": true,
+	"ul": true, "ol": true, "li": true,
+}
+
+// Elements that start a new line. Calendar doesn't render them, so a
+// <br> takes their place.
+var blockElements = map[string]bool{
+	"p": true, "div": true, "blockquote": true, "pre": true, "tr": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+}
+
+// Elements whose content should be dropped along with the tags.
+var droppedElements = map[string]bool{
+	"script": true, "style": true, "head": true, "title": true,
+}
+
+// sanitizeHTML removes from s all HTML markup that Calendar doesn't
+// understand, keeping the text. Allowed tags are kept without
+// attributes, except for the href of a link. The text of block elements
+// like paragraphs and headings is kept on lines of its own.
+func sanitizeHTML(s string) (string, error) {
+	var b strings.Builder
+	// lineBreak separates what follows from the text before it, once.
+	lineBreak := func() {
+		if b.Len() > 0 && !strings.HasSuffix(b.String(), "<br>") {
+			b.WriteString("<br>")
+		}
+	}
+	z := html.NewTokenizer(strings.NewReader(s))
+	skip := 0 // depth inside dropped elements
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if z.Err() != io.EOF {
+				return "", fmt.Errorf("sanitizing description: %v", z.Err())
+			}
+			out := strings.TrimSpace(b.String())
+			for strings.HasSuffix(out, "<br>") {
+				out = strings.TrimSpace(strings.TrimSuffix(out, "<br>"))
+			}
+			return out, nil
+		}
+		tok := z.Token()
+		if skip == 0 && blockElements[tok.Data] && tt != html.TextToken {
+			lineBreak()
+			continue
+		}
+		switch tt {
+		case html.TextToken:
+			if skip == 0 {
+				b.WriteString(html.EscapeString(tok.Data))
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if droppedElements[tok.Data] {
+				if tt == html.StartTagToken {
+					skip++
+				}
+				continue
+			}
+			if skip > 0 || !allowedTags[tok.Data] {
+				continue
+			}
+			b.WriteString("<" + tok.Data)
+			if tok.Data == "a" {
+				for _, a := range tok.Attr {
+					if a.Key == "href" && safeURL(a.Val) {
+						b.WriteString(` href="` + html.EscapeString(a.Val) + `"`)
+					}
+				}
+			}
+			b.WriteString(">")
+		case html.EndTagToken:
+			if droppedElements[tok.Data] {
+				if skip > 0 {
+					skip--
+				}
+				continue
+			}
+			if skip == 0 && allowedTags[tok.Data] && tok.Data != "br" {
+				b.WriteString("</" + tok.Data + ">")
+			}
+		}
+	}
+}
+
+func safeURL(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil {
+		return false
+	}
+	switch u.Scheme {
+	case "http", "https", "mailto":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"regexp"
+	"time"
+
+	api "google.golang.org/api/calendar/v3"
+)
+
+var (
+	searchFlags = flag.NewFlagSet("search", flag.ExitOnError)
+	searchRegex = searchFlags.String("regex", "", "only show events whose summary, description or location match this regular expression")
+	searchFrom  = searchFlags.String("from", "", "only search events ending after this time")
+	searchTo    = searchFlags.String("to", "", "only search events starting before this time")
+	searchMax   = searchFlags.Int("max", 0, "show at most this many events (0 for no limit)")
+	searchFmt   = searchFlags.String("format", "", "Go template for each event's line, as for cal list")
+	searchOut   = searchFlags.String("o", "text", "output format: text or tsv, as for cal list")
+	searchNul   = searchFlags.Bool("print0", false, "end each event's line with a NUL byte instead of a newline")
+)
+
+func runSearch(ctx context.Context, args []string) {
+	if len(args) > 1 {
+		log.Fatal("need at most one query; quote it if it has spaces")
+	}
+	var query string
+	if len(args) == 1 {
+		query = args[0]
+	}
+	var re *regexp.Regexp
+	if *searchRegex != "" {
+		var err error
+		re, err = regexp.Compile(*searchRegex)
+		if err != nil {
+			log.Fatalf("-regex: %v", err)
+		}
+	}
+	if query == "" && re == nil {
+		log.Fatal("need a query or -regex")
+	}
+	var q listQuery
+	var err error
+	if *searchFrom != "" {
+		if q.from, err = parseListTime(*searchFrom); err != nil {
+			log.Fatalf("-from: %v", err)
+		}
+	}
+	if *searchTo != "" {
+		if q.to, err = parseListTime(*searchTo); err != nil {
+			log.Fatalf("-to: %v", err)
+		}
+	}
+	q.max = *searchMax
+	p, err := newListPrinter(*searchFmt, *searchOut, *searchNul)
+	if err != nil {
+		log.Fatal(err)
+	}
+	searchEvents(ctx, newService(ctx), calID, query, re, q, p)
+}
+
+// searchEvents prints the events that the API matches with query and
+// that re, if non-nil, also matches. Each line starts with the event ID,
+// so the output can be piped to "cal delete -stdin". The matches are also
+// saved for completing event IDs.
+func searchEvents(ctx context.Context, c *api.Service, calID, query string, re *regexp.Regexp, q listQuery, p *listPrinter) {
+	call := c.Events.List(calID).Context(ctx)
+	call.SingleEvents(true)
+	call.OrderBy("startTime")
+	if query != "" {
+		call.Q(query)
+	}
+	if !q.from.IsZero() {
+		call.TimeMin(q.from.Format(time.RFC3339))
+	}
+	if !q.to.IsZero() {
+		call.TimeMax(q.to.Format(time.RFC3339))
+	}
+	var found []string
+	add, flush := sortTies(func(e *api.Event) error {
+		if q.max > 0 && len(found) >= q.max {
+			return errEnough
+		}
+		line := completionLine(e)
+		found = append(found, line)
+		if jsonOut {
+			emitJSON(newEventRecord("", e))
+			return nil
+		}
+		return p.print(e, "", line)
+	})
+	err := call.Pages(ctx, func(events *api.Events) error {
+		for _, e := range events.Items {
+			if re != nil && !re.MatchString(e.Summary) && !re.MatchString(e.Description) && !re.MatchString(e.Location) {
+				continue
+			}
+			if err := add(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil && err != errEnough {
+		log.Fatal(err)
+	}
+	saveCompletions("events", found)
+}
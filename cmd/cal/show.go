@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	api "google.golang.org/api/calendar/v3"
+)
+
+// With -json, events are printed as the API returns them.
+var showFlags = flag.NewFlagSet("show", flag.ExitOnError)
+
+func runShow(ctx context.Context, args []string) {
+	if len(args) == 0 {
+		log.Fatal("no event IDs")
+	}
+	client := newService(ctx)
+	for i, eid := range args {
+		ev, err := client.Events.Get(calID, eid).Context(ctx).Do()
+		if err != nil {
+			log.Fatalf("%s: %v", eid, err)
+		}
+		if jsonOut {
+			data, err := json.MarshalIndent(ev, "", "  ")
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("%s\n", data)
+			continue
+		}
+		if i > 0 {
+			fmt.Println()
+		}
+		printEvent(os.Stdout, ev)
+	}
+}
+
+// printEvent writes the fields of ev that are set, one per line.
+func printEvent(w io.Writer, ev *api.Event) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(tw, "%s:\t%s\n", name, value)
+		}
+	}
+	field("id", ev.Id)
+	field("summary", ev.Summary)
+	if ev.Start != nil {
+		field("start", eventTime(ev.Start))
+		field("start zone", ev.Start.TimeZone)
+	}
+	if ev.End != nil {
+		field("end", eventTime(ev.End))
+	}
+	field("status", ev.Status)
+	field("location", ev.Location)
+	for _, r := range ev.Recurrence {
+		field("recurrence", r)
+	}
+	field("recurring event", ev.RecurringEventId)
+	if ev.Organizer != nil {
+		field("organizer", ev.Organizer.Email)
+	}
+	for _, a := range ev.Attendees {
+		s := a.Email
+		if a.DisplayName != "" {
+			s = fmt.Sprintf("%s <%s>", a.DisplayName, a.Email)
+		}
+		if a.Optional {
+			s += " (optional)"
+		}
+		field("attendee", s+" "+a.ResponseStatus)
+	}
+	if r := ev.Reminders; r != nil {
+		if r.UseDefault {
+			field("reminders", "default")
+		}
+		for _, o := range r.Overrides {
+			field("reminder", fmt.Sprintf("%s %d minutes before", o.Method, o.Minutes))
+		}
+	}
+	if cd := ev.ConferenceData; cd != nil {
+		if cd.ConferenceSolution != nil {
+			field("conference", cd.ConferenceSolution.Name)
+		}
+		for _, ep := range cd.EntryPoints {
+			field("join ("+ep.EntryPointType+")", ep.Uri)
+		}
+	}
+	field("hangout", ev.HangoutLink)
+	if ev.Source != nil {
+		field("url", ev.Source.Url)
+	}
+	field("visibility", ev.Visibility)
+	field("transparency", ev.Transparency)
+	field("color", ev.ColorId)
+	field("created", ev.Created)
+	field("updated", ev.Updated)
+	field("link", ev.HtmlLink)
+	if ev.Description != "" {
+		// Indent continuation lines so they stay under the value column.
+		lines := strings.Split(ev.Description, "\n")
+		fmt.Fprintf(tw, "description:\t%s\n", lines[0])
+		for _, l := range lines[1:] {
+			fmt.Fprintf(tw, "\t%s\n", l)
+		}
+	}
+	tw.Flush()
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// readSource returns the contents of an events file, which may be
+// a filename, "-" for standard input, an http or https URL, or a Cloud
+// Storage (gs://) or S3 (s3://) object.
+func readSource(name string) ([]byte, error) {
+	switch {
+	case name == "-":
+		return ioutil.ReadAll(os.Stdin)
+	case strings.HasPrefix(name, "https://"), strings.HasPrefix(name, "http://"):
+		return fetchURL(rawURL(name))
+	case strings.HasPrefix(name, "gs://"):
+		return readGCS(name)
+	case strings.HasPrefix(name, "s3://"):
+		return readS3(name)
+	}
+	return ioutil.ReadFile(name)
+}
+
+func isURL(name string) bool {
+	return strings.Contains(name, "://")
+}
+
+// sourceExt returns the extension of an events file name or URL.
+func sourceExt(name string) string {
+	if isURL(name) {
+		if u, err := url.Parse(name); err == nil {
+			return path.Ext(u.Path)
+		}
+	}
+	return filepath.Ext(name)
+}
+
+// rawURL rewrites links to gists and files on GitHub to the URLs of
+// their raw contents. Other URLs are returned unchanged.
+func rawURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return s
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	switch u.Host {
+	case "gist.github.com":
+		// gist.github.com/USER/ID
+		if len(parts) == 2 {
+			return "https://gist.githubusercontent.com/" + parts[0] + "/" + parts[1] + "/raw"
+		}
+	case "github.com":
+		// github.com/OWNER/REPO/blob/REF/PATH...
+		if len(parts) >= 5 && parts[2] == "blob" {
+			return "https://raw.githubusercontent.com/" + strings.Join(append(parts[:2:2], parts[3:]...), "/")
+		}
+	}
+	return s
+}
+
+// fetchURL gets the contents of u, keeping a copy in the user's cache
+// directory. If the server says the contents haven't changed since the
+// last fetch, the cached copy is used.
+func fetchURL(u string) ([]byte, error) {
+	bodyFile, etagFile := cacheFiles(u)
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	cached, cerr := ioutil.ReadFile(bodyFile)
+	if cerr == nil {
+		if etag, err := ioutil.ReadFile(etagFile); err == nil {
+			req.Header.Set("If-None-Match", string(etag))
+		}
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusNotModified:
+		if cerr != nil {
+			return nil, fmt.Errorf("%s: not modified, but no cached copy: %v", u, cerr)
+		}
+		return cached, nil
+	case http.StatusOK:
+	default:
+		return nil, fmt.Errorf("%s: %s", u, res.Status)
+	}
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if bodyFile != "" {
+		if err := writeCache(bodyFile, etagFile, data, res.Header.Get("ETag")); err != nil {
+			slog.Warn(fmt.Sprintf("caching %s: %v", u, err))
+		}
+	}
+	return data, nil
+}
+
+// cacheDir returns the directory where cal keeps its state: -cache-dir
+// if set, otherwise $CAL_CACHE_DIR, otherwise cal under the user's cache
+// directory. It returns "" if there is none.
+func cacheDir() string {
+	if cacheDirFlag != "" {
+		return cacheDirFlag
+	}
+	if d := os.Getenv("CAL_CACHE_DIR"); d != "" {
+		return d
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "cal")
+}
+
+// cacheFiles returns the names of the files holding the cached body and
+// ETag of u. They are empty if there is no cache directory.
+func cacheFiles(u string) (body, etag string) {
+	dir := cacheDir()
+	if dir == "" {
+		return "", ""
+	}
+	base := filepath.Join(dir, "events", fmt.Sprintf("%x", sha256.Sum256([]byte(u))))
+	return base + ".body", base + ".etag"
+}
+
+func writeCache(bodyFile, etagFile string, data []byte, etag string) error {
+	if err := os.MkdirAll(filepath.Dir(bodyFile), 0700); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(bodyFile, data, 0600); err != nil {
+		return err
+	}
+	if etag == "" {
+		os.Remove(etagFile)
+		return nil
+	}
+	return ioutil.WriteFile(etagFile, []byte(etag), 0600)
+}
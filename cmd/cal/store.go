@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	api "google.golang.org/api/calendar/v3"
+)
+
+// Layout for a wall time, which has no zone offset.
+const wallLayout = "2006-01-02T15:04:05"
+
+// How event times are stored, from -store-as.
+//
+//	offset: the local time with its UTC offset, e.g. 2018-01-17T17:30:00-05:00.
+//	wall:   the local wall time with the zone name, e.g. 2018-01-17T17:30:00 in
+//	        America/New_York. The time stays put across DST changes.
+//	utc:    the instant in UTC, e.g. 2018-01-17T22:30:00Z. Use this for
+//	        events like global calls that happen at a fixed instant.
+var storeMode = "offset"
+
+// The zone name used for wall times.
+var storeZone string
+
+func setStoreMode(mode string) error {
+	switch mode {
+	case "offset", "utc":
+	case "wall":
+		z, err := inputZoneName()
+		if err != nil {
+			return err
+		}
+		storeZone = z
+	default:
+		return fmt.Errorf("unknown mode %q; choices are offset, wall, utc", mode)
+	}
+	storeMode = mode
+	return nil
+}
+
+// eventDateTime returns t in the form selected by -store-as.
+func eventDateTime(t time.Time) *api.EventDateTime {
+	switch storeMode {
+	case "utc":
+		return &api.EventDateTime{DateTime: t.UTC().Format(time.RFC3339)}
+	case "wall":
+		loc, err := time.LoadLocation(storeZone)
+		if err == nil {
+			t = t.In(loc)
+		}
+		return &api.EventDateTime{DateTime: t.Format(wallLayout), TimeZone: storeZone}
+	default:
+		return &api.EventDateTime{DateTime: t.Format(time.RFC3339)}
+	}
+}
+
+// parseDateTime returns the instant of a timed EventDateTime, whichever
+// way it was stored.
+func parseDateTime(dt *api.EventDateTime) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, dt.DateTime); err == nil {
+		return t, nil
+	}
+	loc := inputLoc
+	if dt.TimeZone != "" {
+		var err error
+		loc, err = time.LoadLocation(dt.TimeZone)
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+	return time.ParseInLocation(wallLayout, dt.DateTime, loc)
+}
+
+// The zone in which times in input files and flags are read, from
+// -timezone. It is the local zone by default.
+var inputLoc = time.Local
+
+// setInputZone sets inputLoc from an IANA zone name.
+func setInputZone(name string) error {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return err
+	}
+	inputLoc = loc
+	return nil
+}
+
+// inputZoneName returns the IANA name of inputLoc.
+func inputZoneName() (string, error) {
+	if inputLoc != time.Local {
+		return inputLoc.String(), nil
+	}
+	return localZoneName()
+}
+
+// localZoneName returns the IANA name of the local time zone, which
+// time.Local doesn't expose.
+func localZoneName() (string, error) {
+	if tz := strings.TrimPrefix(os.Getenv("TZ"), ":"); tz != "" {
+		if _, err := time.LoadLocation(tz); err == nil {
+			return tz, nil
+		}
+	}
+	if p, err := filepath.EvalSymlinks("/etc/localtime"); err == nil {
+		if _, name, ok := strings.Cut(p, "zoneinfo/"); ok {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("cannot determine the name of the local time zone; set TZ")
+}
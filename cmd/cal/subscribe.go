@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	api "google.golang.org/api/calendar/v3"
+)
+
+var (
+	subscribeFlags   = flag.NewFlagSet("subscribe", flag.ExitOnError)
+	subscribeColor   = subscribeFlags.String("color", "", "color of the calendar: a name like graphite or tomato, or #rrggbb")
+	subscribeHidden  = subscribeFlags.Bool("hidden", false, "hide the calendar in the Calendar UI")
+	subscribeSummary = subscribeFlags.String("summary", "", "name to show for the calendar instead of its own")
+
+	unsubscribeFlags = flag.NewFlagSet("unsubscribe", flag.ExitOnError)
+)
+
+// calendarColors are the names the Calendar UI gives its colors.
+var calendarColors = map[string]string{
+	"tomato":    "#d50000",
+	"flamingo":  "#e67c73",
+	"tangerine": "#f4511e",
+	"banana":    "#f6bf26",
+	"sage":      "#33b679",
+	"basil":     "#0b8043",
+	"peacock":   "#039be5",
+	"blueberry": "#3f51b5",
+	"lavender":  "#7986cb",
+	"grape":     "#8e24aa",
+	"graphite":  "#616161",
+}
+
+// A subscriptionRecord is the -json form of a calendar that was subscribed
+// to or unsubscribed from.
+type subscriptionRecord struct {
+	Action   string `json:"action"`
+	Calendar string `json:"calendar"`
+	Summary  string `json:"summary,omitempty"`
+	Color    string `json:"color,omitempty"`
+	Hidden   bool   `json:"hidden,omitempty"`
+}
+
+// runSubscribe adds the -id calendar to the user's calendar list, or, if
+// it is already there, changes the settings given by flags.
+func runSubscribe(ctx context.Context, args []string) {
+	set := map[string]bool{}
+	subscribeFlags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	entry := &api.CalendarListEntry{Id: calID}
+	if *subscribeColor != "" {
+		bg, err := parseColor(*subscribeColor)
+		if err != nil {
+			log.Fatalf("-color: %v", err)
+		}
+		entry.BackgroundColor = bg
+		entry.ForegroundColor = foregroundFor(bg)
+	}
+	if set["hidden"] {
+		entry.Hidden = *subscribeHidden
+		entry.ForceSendFields = append(entry.ForceSendFields, "Hidden")
+	}
+	entry.SummaryOverride = *subscribeSummary
+
+	c := newService(ctx)
+	action := "subscribed"
+	var res *api.CalendarListEntry
+	if _, err := c.CalendarList.Get(calID).Context(ctx).Do(); err == nil {
+		action = "updated"
+		entry.Id = ""
+		res, err = c.CalendarList.Patch(calID, entry).ColorRgbFormat(entry.BackgroundColor != "").Context(ctx).Do()
+		if err != nil {
+			log.Fatalf("%s: %v", calID, err)
+		}
+	} else {
+		res, err = c.CalendarList.Insert(entry).ColorRgbFormat(entry.BackgroundColor != "").Context(ctx).Do()
+		if err != nil {
+			log.Fatalf("%s: %v", calID, err)
+		}
+	}
+	summary := res.SummaryOverride
+	if summary == "" {
+		summary = res.Summary
+	}
+	if jsonOut {
+		emitJSON(subscriptionRecord{action, res.Id, summary, res.BackgroundColor, res.Hidden})
+		return
+	}
+	fmt.Printf("%s %s\t%q\tcolor:%s hidden:%t\n", action, res.Id, summary, res.BackgroundColor, res.Hidden)
+}
+
+// runUnsubscribe removes the -id calendar from the user's calendar list.
+// The calendar itself is not deleted.
+func runUnsubscribe(ctx context.Context, args []string) {
+	if err := newService(ctx).CalendarList.Delete(calID).Context(ctx).Do(); err != nil {
+		log.Fatalf("%s: %v", calID, err)
+	}
+	if jsonOut {
+		emitJSON(subscriptionRecord{Action: "unsubscribed", Calendar: calID})
+		return
+	}
+	fmt.Printf("unsubscribed %s\n", calID)
+}
+
+// parseColor returns the #rrggbb form of a color name from calendarColors
+// or of a #rrggbb color.
+func parseColor(s string) (string, error) {
+	if c, ok := calendarColors[strings.ToLower(s)]; ok {
+		return c, nil
+	}
+	if len(s) == 7 && s[0] == '#' {
+		if _, err := strconv.ParseUint(s[1:], 16, 32); err == nil {
+			return strings.ToLower(s), nil
+		}
+	}
+	return "", fmt.Errorf("bad color %q: want #rrggbb or one of tomato, flamingo, tangerine, banana, sage, basil, peacock, blueberry, lavender, grape, graphite", s)
+}
+
+// foregroundFor returns black or white, whichever is easier to read on
+// the #rrggbb color bg.
+func foregroundFor(bg string) string {
+	n, _ := strconv.ParseUint(bg[1:], 16, 32)
+	r, g, b := n>>16, n>>8&0xff, n&0xff
+	if 299*r+587*g+114*b > 150*1000 {
+		return "#000000"
+	}
+	return "#ffffff"
+}
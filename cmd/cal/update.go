@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	api "google.golang.org/api/calendar/v3"
+)
+
+var (
+	updateFlags    = flag.NewFlagSet("update", flag.ExitOnError)
+	updateSummary  = updateFlags.String("summary", "", "new summary")
+	updateStart    = updateFlags.String("start", "", "new start time; the end moves with it unless -end is also given")
+	updateEnd      = updateFlags.String("end", "", "new end time")
+	updateDesc     = updateFlags.String("desc", "", "new description; -desc '' removes it")
+	updateLocation = updateFlags.String("location", "", "new location; -location '' removes it")
+	updateGrammars = updateFlags.String("grammars", "long,iso", "comma-separated date formats to try, in order: long, iso, us, eu")
+	updateDoit     = updateFlags.Bool("doit", false, "nothing is changed unless this is provided")
+)
+
+func runUpdate(ctx context.Context, args []string) {
+	if len(args) != 1 {
+		log.Fatal("need exactly one event ID")
+	}
+	eid := args[0]
+	if err := setGrammars(*updateGrammars); err != nil {
+		log.Fatalf("-grammars: %v", err)
+	}
+	set := map[string]bool{}
+	updateFlags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	client := newClient(ctx)
+	old, err := client.Service().Events.Get(calID, eid).Context(ctx).Do()
+	if err != nil {
+		log.Fatalf("%s: %v", eid, err)
+	}
+	patch, err := eventPatch(old, set)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if patch == nil {
+		log.Fatal("nothing to change; provide at least one of -summary, -start, -end, -desc, -location")
+	}
+	if !*updateDoit {
+		after := applyPatch(old, patch)
+		if jsonOut {
+			emitJSON(newEventRecord("would update", after))
+		} else {
+			fmt.Printf("would update %s\t%s\t%q\n", eid, eventTime(after.Start), after.Summary)
+			for _, c := range patchChanges(old, after) {
+				fmt.Printf("\t%s\n", c)
+			}
+		}
+		info("provide -doit to update\n")
+		return
+	}
+	ev, err := client.PatchEvent(ctx, calID, eid, patch)
+	if err != nil {
+		log.Fatalf("%s: %v", eid, err)
+	}
+	if jsonOut {
+		emitJSON(newEventRecord("updated", ev))
+		return
+	}
+	fmt.Printf("updated %s\t%s\t%q\n", eid, eventTime(ev.Start), ev.Summary)
+}
+
+// eventPatch returns the changes to old requested by the flags in set,
+// or nil if there are none. Only the fields it changes are validated, so
+// an event with, say, no summary can still get a location.
+func eventPatch(old *api.Event, set map[string]bool) (*api.Event, error) {
+	patch := &api.Event{}
+	changed := false
+	summary := old.Summary
+	if set["summary"] {
+		summary = strings.TrimSpace(*updateSummary)
+		if err := validateSummary(summary); err != nil {
+			return nil, err
+		}
+		patch.Summary = summary
+		changed = true
+	}
+	if set["desc"] {
+		patch.Description = *updateDesc
+		if err := validateDescription(summary, patch.Description); err != nil {
+			return nil, err
+		}
+		patch.ForceSendFields = append(patch.ForceSendFields, "Description")
+		changed = true
+	}
+	if set["location"] {
+		patch.Location = *updateLocation
+		patch.ForceSendFields = append(patch.ForceSendFields, "Location")
+		changed = true
+	}
+	if set["start"] || set["end"] {
+		if old.Start == nil || old.Start.DateTime == "" || old.End == nil || old.End.DateTime == "" {
+			return nil, fmt.Errorf("%s: cannot change the times of an all-day event", old.Id)
+		}
+		start, err := parseDateTime(old.Start)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseDateTime(old.End)
+		if err != nil {
+			return nil, err
+		}
+		dur := end.Sub(start)
+		if set["start"] {
+			if start, err = parseTime(*updateStart); err != nil {
+				return nil, err
+			}
+			end = start.Add(dur)
+		}
+		if set["end"] {
+			if end, err = parseTime(*updateEnd); err != nil {
+				return nil, err
+			}
+		}
+		if !end.After(start) {
+			return nil, fmt.Errorf("end %s is not after start %s", end.Format(time.RFC3339), start.Format(time.RFC3339))
+		}
+		patch.Start = patchTime(start, old.Start)
+		patch.End = patchTime(end, old.End)
+		changed = true
+	}
+	if !changed {
+		return nil, nil
+	}
+	return patch, nil
+}
+
+// patchTime returns t as a new value for old. If old has a time zone, t
+// is given in it, so that the event keeps its zone; otherwise t is stored
+// as -store-as says.
+func patchTime(t time.Time, old *api.EventDateTime) *api.EventDateTime {
+	if old.TimeZone != "" {
+		if loc, err := time.LoadLocation(old.TimeZone); err == nil {
+			return &api.EventDateTime{DateTime: t.In(loc).Format(wallLayout), TimeZone: old.TimeZone}
+		}
+	}
+	return eventDateTime(t)
+}
+
+// applyPatch returns a copy of old with the fields of patch, as made by
+// eventPatch, applied.
+func applyPatch(old, patch *api.Event) *api.Event {
+	e := *old
+	if patch.Summary != "" {
+		e.Summary = patch.Summary
+	}
+	if slices.Contains(patch.ForceSendFields, "Description") {
+		e.Description = patch.Description
+	}
+	if slices.Contains(patch.ForceSendFields, "Location") {
+		e.Location = patch.Location
+	}
+	if patch.Start != nil {
+		e.Start, e.End = patch.Start, patch.End
+	}
+	return &e
+}
+
+// patchChanges describes the fields that differ between old and after,
+// one per line, as "field: old -> new".
+func patchChanges(old, after *api.Event) []string {
+	var cs []string
+	add := func(field, o, n string) {
+		if o != n {
+			cs = append(cs, fmt.Sprintf("%s: %q -> %q", field, o, n))
+		}
+	}
+	add("summary", old.Summary, after.Summary)
+	if after.Start != old.Start {
+		add("start", eventTime(old.Start), eventTime(after.Start))
+		add("end", eventTime(old.End), eventTime(after.End))
+	}
+	add("desc", old.Description, after.Description)
+	add("location", old.Location, after.Location)
+	return cs
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	api "google.golang.org/api/calendar/v3"
+)
+
+var (
+	vacationFlags   = flag.NewFlagSet("vacation", flag.ExitOnError)
+	vacationFrom    = vacationFlags.String("from", "", "first day away, like 2026-12-21")
+	vacationTo      = vacationFlags.String("to", "", "last day away, inclusive")
+	vacationSummary = vacationFlags.String("summary", "Out of office", "summary of the out-of-office event")
+	vacationMessage = vacationFlags.String("message", "", "message sent with declined invitations")
+	vacationDecline = vacationFlags.Bool("decline-existing", false, "also decline meetings already accepted or pending in that time")
+	vacationAuto    = vacationFlags.Bool("auto-decline", true, "decline new invitations in that time")
+	vacationDoit    = vacationFlags.Bool("doit", false, "nothing is changed unless this is provided")
+)
+
+// runVacation blocks out days with an out-of-office event. Calendar
+// itself declines conflicting invitations, as the event's decline mode
+// says; the dry run lists the meetings that -decline-existing would
+// decline.
+func runVacation(ctx context.Context, args []string) {
+	if *vacationFrom == "" || *vacationTo == "" {
+		log.Fatal("need -from and -to")
+	}
+	first, err := time.ParseInLocation(time.DateOnly, *vacationFrom, inputLoc)
+	if err != nil {
+		log.Fatalf("-from: %v", err)
+	}
+	last, err := time.ParseInLocation(time.DateOnly, *vacationTo, inputLoc)
+	if err != nil {
+		log.Fatalf("-to: %v", err)
+	}
+	if last.Before(first) {
+		log.Fatal("-to is before -from")
+	}
+	end := last.AddDate(0, 0, 1)
+	zone, err := inputZoneName()
+	if err != nil {
+		log.Fatal(err)
+	}
+	mode := "declineNone"
+	switch {
+	case *vacationDecline:
+		mode = "declineAllConflictingInvitations"
+	case *vacationAuto:
+		mode = "declineOnlyNewConflictingInvitations"
+	}
+	// Out-of-office events can't be all-day events, so the event runs
+	// from midnight to midnight.
+	ev := &api.Event{
+		Summary:   *vacationSummary,
+		EventType: "outOfOffice",
+		Start:     &api.EventDateTime{DateTime: first.Format(wallLayout), TimeZone: zone},
+		End:       &api.EventDateTime{DateTime: end.Format(wallLayout), TimeZone: zone},
+		OutOfOfficeProperties: &api.EventOutOfOfficeProperties{
+			AutoDeclineMode: mode,
+			DeclineMessage:  *vacationMessage,
+		},
+	}
+
+	client := newClient(ctx)
+	if *vacationDecline && !*vacationDoit {
+		q := listQuery{from: first, to: end}
+		err := eachEvent(ctx, client.Service(), calID, q, func(e *api.Event) error {
+			if invitedToDecline(e) {
+				if jsonOut {
+					emitJSON(newEventRecord("would decline", e))
+				} else {
+					fmt.Printf("would decline %s\t%s\t%q\n", e.Id, eventTime(e.Start), e.Summary)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if !*vacationDoit {
+		if jsonOut {
+			emitJSON(newEventRecord("would insert", ev))
+		} else {
+			fmt.Printf("would insert\t%s - %s\t%q\t%s\n", eventTime(ev.Start), eventTime(ev.End), ev.Summary, mode)
+		}
+		info("provide -doit to insert\n")
+		return
+	}
+	iev, err := client.InsertEvent(ctx, calID, ev)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if jsonOut {
+		emitJSON(newEventRecord("inserted", iev))
+		return
+	}
+	fmt.Printf("inserted %s\t%s - %s\t%q\t%s\n", iev.Id, eventTime(iev.Start), eventTime(iev.End), iev.Summary, mode)
+}
+
+// invitedToDecline reports whether e is a meeting that the calendar's
+// owner was invited to and hasn't declined.
+func invitedToDecline(e *api.Event) bool {
+	if e.Transparency == "transparent" {
+		return false
+	}
+	for _, a := range e.Attendees {
+		if a.Self {
+			return !a.Organizer && a.ResponseStatus != "declined"
+		}
+	}
+	return false
+}
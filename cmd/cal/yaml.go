@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	api "google.golang.org/api/calendar/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// A yamlEvent is an event in a YAML events file, which is a list of them:
+//
+//   - date: 2026 October 20
+//     start: 7pm
+//     end: 9pm
+//     summary: Rehearsal
+//     location: Room 101
+//     color: tomato
+//     attendees: [ann@example.com, {email: bob@example.com, optional: true}]
+//   - date: 2026-10-23
+//     summary: Offsite
+//
+// Date and start together are parsed like the time line of the text
+// format, so -grammars applies; without a date, start must include one.
+// An event with no start is an all-day event on date, which must then be
+// like 2026-10-23.
+type yamlEvent struct {
+	Date        string         `yaml:"date"`
+	Start       string         `yaml:"start"`
+	End         string         `yaml:"end"`
+	Summary     string         `yaml:"summary"`
+	Description string         `yaml:"description"`
+	Location    string         `yaml:"location"`
+	Color       string         `yaml:"color"`
+	Attendees   []yamlAttendee `yaml:"attendees"`
+}
+
+// A yamlAttendee is an email address, or a mapping with email, name and
+// optional.
+type yamlAttendee struct {
+	Email    string `yaml:"email"`
+	Name     string `yaml:"name"`
+	Optional bool   `yaml:"optional"`
+}
+
+func (a *yamlAttendee) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode {
+		a.Email = n.Value
+		return nil
+	}
+	type plain yamlAttendee
+	return n.Decode((*plain)(a))
+}
+
+// readYAML parses a YAML events file.
+func readYAML(data []byte) ([]*api.Event, error) {
+	var yevs []yamlEvent
+	if err := yaml.Unmarshal(data, &yevs); err != nil {
+		return nil, err
+	}
+	var evs []*api.Event
+	for i, ye := range yevs {
+		ev, err := yamlToEvent(ye)
+		if err != nil {
+			return nil, fmt.Errorf("event %d: %v", i+1, err)
+		}
+		evs = append(evs, ev)
+	}
+	return evs, nil
+}
+
+func yamlToEvent(ye yamlEvent) (*api.Event, error) {
+	var ev *api.Event
+	if ye.Start == "" {
+		day, err := time.Parse(time.DateOnly, ye.Date)
+		if err != nil {
+			return nil, fmt.Errorf("%q: no start, and date %q is not like 2026-10-23 for an all-day event", ye.Summary, ye.Date)
+		}
+		desc := ye.Description
+		if *sanitizeDesc {
+			var err error
+			if desc, err = sanitizeHTML(desc); err != nil {
+				return nil, err
+			}
+		}
+		if err := validateText(ye.Summary, desc); err != nil {
+			return nil, err
+		}
+		ev = &api.Event{
+			Summary:     ye.Summary,
+			Description: desc,
+			Location:    ye.Location,
+			Start:       &api.EventDateTime{Date: day.Format(time.DateOnly)},
+			End:         &api.EventDateTime{Date: day.AddDate(0, 0, 1).Format(time.DateOnly)},
+		}
+		if ye.Color != "" {
+			if err := setColor(ev, ye.Color); err != nil {
+				return nil, fmt.Errorf("%q: %v", ye.Summary, err)
+			}
+		}
+	} else {
+		fields := map[string]string{
+			"date":     ye.Date,
+			"start":    ye.Start,
+			"end":      ye.End,
+			"summary":  ye.Summary,
+			"desc":     ye.Description,
+			"location": ye.Location,
+			"color":    ye.Color,
+		}
+		var err error
+		ev, err = fieldsEvent(func(f string) string { return fields[f] })
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, a := range ye.Attendees {
+		if a.Email == "" {
+			return nil, fmt.Errorf("%q: attendee with no email", ye.Summary)
+		}
+		ev.Attendees = append(ev.Attendees, &api.EventAttendee{Email: a.Email, DisplayName: a.Name, Optional: a.Optional})
+	}
+	return ev, nil
+}
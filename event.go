@@ -0,0 +1,265 @@
+// Package calendar holds a model of calendar events that doesn't depend
+// on any one calendar service, along with conversions to and from the
+// Google Calendar API, iCalendar (ICS) and JSON.
+package calendar
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	api "google.golang.org/api/calendar/v3"
+)
+
+// An Event is a calendar event.
+//
+// For an all-day event, Start and End are midnight UTC of the first day
+// and of the day after the last, so a one-day event lasts 24 hours.
+type Event struct {
+	ID          string      `json:"id,omitempty"`  // assigned by the calendar service
+	UID         string      `json:"uid,omitempty"` // iCalendar UID, the same in every copy of the event
+	Summary     string      `json:"summary"`
+	Description string      `json:"description,omitempty"`
+	Location    string      `json:"location,omitempty"`
+	URL         string      `json:"url,omitempty"`         // the event's source, like the page it came from
+	SourceTitle string      `json:"sourceTitle,omitempty"` // the title of the URL, shown as its link
+	Status      string      `json:"status,omitempty"`      // confirmed, tentative or cancelled
+	Start       time.Time   `json:"start"`
+	End         time.Time   `json:"end"`
+	AllDay      bool        `json:"allDay,omitempty"`
+	TimeZone    string      `json:"timeZone,omitempty"` // IANA name; empty means "as given by Start"
+	Recurrence  []Rule      `json:"recurrence,omitempty"`
+	ExDates     []time.Time `json:"exDates,omitempty"` // starts of instances removed from Recurrence
+	Attendees   []Attendee  `json:"attendees,omitempty"`
+	Reminders   *Reminders  `json:"reminders,omitempty"`
+}
+
+// An Attendee is a person or resource invited to an event.
+type Attendee struct {
+	Email    string `json:"email"`
+	Name     string `json:"name,omitempty"`
+	Optional bool   `json:"optional,omitempty"`
+	Response string `json:"response,omitempty"` // needsAction, declined, tentative or accepted
+}
+
+// Reminders says how attendees are reminded of an event.
+type Reminders struct {
+	UseDefault bool       `json:"useDefault,omitempty"`
+	Overrides  []Reminder `json:"overrides,omitempty"`
+}
+
+// A Reminder is sent some minutes before an event starts.
+type Reminder struct {
+	Method  string `json:"method"` // email or popup
+	Minutes int    `json:"minutes"`
+}
+
+// A Rule is a recurrence rule, the RRULE of RFC 5545.
+type Rule struct {
+	Freq     string    `json:"freq"` // DAILY, WEEKLY, MONTHLY or YEARLY
+	Interval int       `json:"interval,omitempty"`
+	Count    int       `json:"count,omitempty"`
+	Until    time.Time `json:"until,omitzero"`
+	ByDay    []string  `json:"byDay,omitempty"` // e.g. MO, or 1FR for the first Friday
+}
+
+// ParseRule parses an RRULE value like "FREQ=WEEKLY;COUNT=10;BYDAY=MO,WE".
+// The "RRULE:" prefix is optional. WKST is accepted and ignored; it
+// changes only which weeks an INTERVAL skips. Other parts than FREQ,
+// INTERVAL, COUNT, UNTIL and BYDAY are rejected, so that nothing is
+// silently dropped.
+func ParseRule(s string) (Rule, error) {
+	var r Rule
+	s = strings.TrimPrefix(s, "RRULE:")
+	for _, part := range strings.Split(s, ";") {
+		k, v, ok := strings.Cut(part, "=")
+		if !ok {
+			return Rule{}, fmt.Errorf("recurrence rule %q: bad part %q", s, part)
+		}
+		var err error
+		switch strings.ToUpper(k) {
+		case "FREQ":
+			r.Freq = strings.ToUpper(v)
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(v)
+		case "COUNT":
+			r.Count, err = strconv.Atoi(v)
+		case "UNTIL":
+			r.Until, err = parseICSTime(v, nil)
+		case "BYDAY":
+			r.ByDay = strings.Split(strings.ToUpper(v), ",")
+		case "WKST":
+		default:
+			return Rule{}, fmt.Errorf("recurrence rule %q: %s is not supported", s, k)
+		}
+		if err != nil {
+			return Rule{}, fmt.Errorf("recurrence rule %q: %s: %v", s, k, err)
+		}
+	}
+	switch r.Freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+	default:
+		return Rule{}, fmt.Errorf("recurrence rule %q: bad or missing FREQ", s)
+	}
+	return r, nil
+}
+
+// String returns r as an RRULE value, without the "RRULE:" prefix.
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(icsUTCLayout))
+	}
+	if len(r.ByDay) > 0 {
+		parts = append(parts, "BYDAY="+strings.Join(r.ByDay, ","))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Layout for a wall time in the Calendar API, which has no zone offset.
+const apiWallLayout = "2006-01-02T15:04:05"
+
+// FromAPI converts a Calendar API event. Recurrence lines other than
+// RRULE and EXDATE, such as RDATE, are an error.
+func FromAPI(ae *api.Event) (*Event, error) {
+	e := &Event{
+		ID:          ae.Id,
+		UID:         ae.ICalUID,
+		Summary:     ae.Summary,
+		Description: ae.Description,
+		Location:    ae.Location,
+		Status:      ae.Status,
+	}
+	if ae.Source != nil {
+		e.URL = ae.Source.Url
+		e.SourceTitle = ae.Source.Title
+	}
+	if ae.Start == nil || ae.End == nil {
+		return nil, fmt.Errorf("event %q: missing start or end", ae.Summary)
+	}
+	var err error
+	if e.Start, e.AllDay, err = fromAPITime(ae.Start); err != nil {
+		return nil, fmt.Errorf("event %q: start: %v", ae.Summary, err)
+	}
+	if e.End, _, err = fromAPITime(ae.End); err != nil {
+		return nil, fmt.Errorf("event %q: end: %v", ae.Summary, err)
+	}
+	e.TimeZone = ae.Start.TimeZone
+	for _, line := range ae.Recurrence {
+		if strings.HasPrefix(line, "EXDATE") {
+			l, err := parseICSLine(line)
+			if err == nil {
+				e.ExDates, err = icsTimes(e.ExDates, l, &icsZones{floating: time.UTC})
+			}
+			if err != nil {
+				return nil, fmt.Errorf("event %q: %q: %v", ae.Summary, line, err)
+			}
+			continue
+		}
+		if !strings.HasPrefix(line, "RRULE:") {
+			return nil, fmt.Errorf("event %q: unsupported recurrence %q", ae.Summary, line)
+		}
+		r, err := ParseRule(line)
+		if err != nil {
+			return nil, fmt.Errorf("event %q: %v", ae.Summary, err)
+		}
+		e.Recurrence = append(e.Recurrence, r)
+	}
+	for _, a := range ae.Attendees {
+		e.Attendees = append(e.Attendees, Attendee{
+			Email:    a.Email,
+			Name:     a.DisplayName,
+			Optional: a.Optional,
+			Response: a.ResponseStatus,
+		})
+	}
+	if ae.Reminders != nil {
+		e.Reminders = &Reminders{UseDefault: ae.Reminders.UseDefault}
+		for _, o := range ae.Reminders.Overrides {
+			e.Reminders.Overrides = append(e.Reminders.Overrides, Reminder{o.Method, int(o.Minutes)})
+		}
+	}
+	return e, nil
+}
+
+func fromAPITime(dt *api.EventDateTime) (t time.Time, allDay bool, err error) {
+	if dt.Date != "" {
+		t, err = time.Parse(time.DateOnly, dt.Date)
+		return t, true, err
+	}
+	if t, err := time.Parse(time.RFC3339, dt.DateTime); err == nil {
+		return t, false, nil
+	}
+	if dt.TimeZone == "" {
+		return time.Time{}, false, fmt.Errorf("%q has no offset and no time zone", dt.DateTime)
+	}
+	loc, err := time.LoadLocation(dt.TimeZone)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	t, err = time.ParseInLocation(apiWallLayout, dt.DateTime, loc)
+	return t, false, err
+}
+
+// ToAPI converts e to a Calendar API event. If e.TimeZone is set, times
+// are sent as wall times in that zone, so they stay put across daylight
+// saving changes.
+func (e *Event) ToAPI() *api.Event {
+	ae := &api.Event{
+		Id:          e.ID,
+		ICalUID:     e.UID,
+		Summary:     e.Summary,
+		Description: e.Description,
+		Location:    e.Location,
+		Status:      e.Status,
+		Start:       e.apiTime(e.Start),
+		End:         e.apiTime(e.End),
+	}
+	if e.URL != "" {
+		ae.Source = &api.EventSource{Title: e.SourceTitle, Url: e.URL}
+	}
+	for _, r := range e.Recurrence {
+		ae.Recurrence = append(ae.Recurrence, "RRULE:"+r.String())
+	}
+	for _, t := range e.ExDates {
+		ae.Recurrence = append(ae.Recurrence, "EXDATE"+e.icsTime(t))
+	}
+	for _, a := range e.Attendees {
+		ae.Attendees = append(ae.Attendees, &api.EventAttendee{
+			Email:          a.Email,
+			DisplayName:    a.Name,
+			Optional:       a.Optional,
+			ResponseStatus: a.Response,
+		})
+	}
+	if e.Reminders != nil {
+		ae.Reminders = &api.EventReminders{UseDefault: e.Reminders.UseDefault}
+		if !e.Reminders.UseDefault {
+			// Otherwise the API takes false to be absent.
+			ae.Reminders.ForceSendFields = []string{"UseDefault"}
+		}
+		for _, o := range e.Reminders.Overrides {
+			ae.Reminders.Overrides = append(ae.Reminders.Overrides, &api.EventReminder{Method: o.Method, Minutes: int64(o.Minutes)})
+		}
+	}
+	return ae
+}
+
+func (e *Event) apiTime(t time.Time) *api.EventDateTime {
+	if e.AllDay {
+		return &api.EventDateTime{Date: t.Format(time.DateOnly)}
+	}
+	if e.TimeZone != "" {
+		if loc, err := time.LoadLocation(e.TimeZone); err == nil {
+			return &api.EventDateTime{DateTime: t.In(loc).Format(apiWallLayout), TimeZone: e.TimeZone}
+		}
+	}
+	return &api.EventDateTime{DateTime: t.Format(time.RFC3339)}
+}
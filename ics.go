@@ -0,0 +1,509 @@
+package calendar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Layouts for iCalendar DATE-TIME and DATE values.
+const (
+	icsUTCLayout   = "20060102T150405Z"
+	icsLocalLayout = "20060102T150405"
+	icsDateLayout  = "20060102"
+)
+
+// ReadICS reads the VEVENTs of an iCalendar file (RFC 5545). Other
+// components, and properties that Event has no place for, are ignored.
+// Times with neither a UTC marker nor a TZID are taken to be local time.
+//
+// A VEVENT with a RECURRENCE-ID changes one instance of the recurring
+// event with the same UID. The instance is added to the ExDates of that
+// event, and unless it was cancelled, the changed instance is returned
+// as an event of its own, without a UID. An RDATE is an error, since
+// Event has no place for extra instances.
+func ReadICS(r io.Reader) ([]*Event, error) {
+	return ReadICSIn(r, time.Local)
+}
+
+// ReadICSIn is like ReadICS, but takes times with neither a UTC marker nor
+// a TZID to be in loc. So are times whose TZID is neither an IANA name, a
+// common Windows zone name, nor defined by a VTIMEZONE. Alarms that have
+// no Reminder form are dropped.
+func ReadICSIn(r io.Reader, loc *time.Location) ([]*Event, error) {
+	lines, err := icsLines(r)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		evs       []*Event
+		ev        *Event
+		alarm     *Reminder
+		dur       time.Duration
+		depth     int // of components nested in the VEVENT, like VALARM
+		zones     = &icsZones{floating: loc}
+		inZone    bool
+		recurID   time.Time
+		overrides = map[*Event]time.Time{} // by event, its RECURRENCE-ID
+	)
+	for _, l := range lines {
+		switch {
+		case l.name == "BEGIN" && l.value == "VEVENT":
+			ev, dur, depth, recurID = &Event{}, -1, 0, time.Time{}
+			continue
+		case ev == nil:
+			switch {
+			case l.name == "BEGIN" && l.value == "VTIMEZONE":
+				inZone, zones.tzid = true, ""
+			case l.name == "END" && l.value == "VTIMEZONE":
+				inZone = false
+			case inZone:
+				zones.add(l)
+			}
+			continue
+		case l.name == "BEGIN":
+			depth++
+			if l.value == "VALARM" {
+				alarm = &Reminder{Method: "popup"}
+			}
+			continue
+		case l.name == "END" && l.value == "VEVENT":
+			if err := finishICSEvent(ev, dur); err != nil {
+				return nil, fmt.Errorf("line %d: %v", l.num, err)
+			}
+			if !recurID.IsZero() {
+				overrides[ev] = recurID
+			}
+			evs = append(evs, ev)
+			ev = nil
+			continue
+		case l.name == "END":
+			depth--
+			if l.value == "VALARM" && alarm != nil {
+				if ev.Reminders == nil {
+					ev.Reminders = &Reminders{}
+				}
+				ev.Reminders.Overrides = append(ev.Reminders.Overrides, *alarm)
+				alarm = nil
+			}
+			continue
+		}
+		if depth > 0 {
+			if alarm != nil && !setAlarmProperty(alarm, l) {
+				// Reminders can only come before the start.
+				alarm = nil
+			}
+			continue
+		}
+		if l.name == "RECURRENCE-ID" {
+			if recurID, _, err = icsTime(l, zones); err != nil {
+				return nil, fmt.Errorf("line %d: %s: %v", l.num, l.name, err)
+			}
+			continue
+		}
+		if err := setICSProperty(ev, &dur, l, zones); err != nil {
+			return nil, fmt.Errorf("line %d: %s: %v", l.num, l.name, err)
+		}
+	}
+	if ev != nil {
+		return nil, fmt.Errorf("unterminated VEVENT")
+	}
+	return applyICSOverrides(evs, overrides), nil
+}
+
+// applyICSOverrides removes the instances changed by the events in
+// overrides from their recurring events; see ReadICS.
+func applyICSOverrides(evs []*Event, overrides map[*Event]time.Time) []*Event {
+	if len(overrides) == 0 {
+		return evs
+	}
+	series := map[string]*Event{} // by UID
+	for _, ev := range evs {
+		if _, ok := overrides[ev]; !ok && ev.UID != "" && len(ev.Recurrence) > 0 {
+			series[ev.UID] = ev
+		}
+	}
+	var out []*Event
+	for _, ev := range evs {
+		id, ok := overrides[ev]
+		if s := series[ev.UID]; ok && s != nil {
+			s.ExDates = append(s.ExDates, id)
+			if ev.Status == "cancelled" {
+				continue
+			}
+			// The UID belongs to the recurring event.
+			ev.UID = ""
+		}
+		out = append(out, ev)
+	}
+	return out
+}
+
+// An icsLine is an unfolded content line.
+type icsLine struct {
+	num    int // of the first physical line
+	name   string
+	params map[string]string
+	value  string
+}
+
+// icsLines reads and unfolds the content lines of r.
+func icsLines(r io.Reader) ([]icsLine, error) {
+	var (
+		raw  []string
+		nums []int
+	)
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 1<<20)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSuffix(s.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(raw) > 0 {
+			raw[len(raw)-1] += line[1:]
+			continue
+		}
+		if line == "" {
+			continue
+		}
+		raw = append(raw, line)
+		nums = append(nums, n)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	var lines []icsLine
+	for i, line := range raw {
+		l, err := parseICSLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", nums[i], err)
+		}
+		l.num = nums[i]
+		lines = append(lines, l)
+	}
+	return lines, nil
+}
+
+// parseICSLine parses a line like DTSTART;TZID=Europe/Berlin:20180117T173000.
+func parseICSLine(line string) (icsLine, error) {
+	// The value starts at the first colon that isn't in a quoted parameter.
+	quoted := false
+	colon := -1
+	for i := 0; i < len(line) && colon < 0; i++ {
+		switch line[i] {
+		case '"':
+			quoted = !quoted
+		case ':':
+			if !quoted {
+				colon = i
+			}
+		}
+	}
+	if colon < 0 {
+		return icsLine{}, fmt.Errorf("no colon in %q", line)
+	}
+	l := icsLine{value: line[colon+1:]}
+	parts := strings.Split(line[:colon], ";")
+	l.name = strings.ToUpper(parts[0])
+	for _, p := range parts[1:] {
+		k, v, _ := strings.Cut(p, "=")
+		if l.params == nil {
+			l.params = map[string]string{}
+		}
+		l.params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+	}
+	return l, nil
+}
+
+// setICSProperty sets the field of ev for l. A DURATION is stored in *dur,
+// because it can come before DTSTART.
+func setICSProperty(ev *Event, dur *time.Duration, l icsLine, zones *icsZones) error {
+	var err error
+	switch l.name {
+	case "UID":
+		ev.UID = l.value
+	case "SUMMARY":
+		ev.Summary = unescapeICSText(l.value)
+	case "DESCRIPTION":
+		ev.Description = unescapeICSText(l.value)
+	case "LOCATION":
+		ev.Location = unescapeICSText(l.value)
+	case "URL":
+		ev.URL = l.value
+	case "STATUS":
+		ev.Status = strings.ToLower(l.value)
+	case "DTSTART":
+		ev.Start, ev.AllDay, err = icsTime(l, zones)
+		if tz := l.params["TZID"]; tz != "" {
+			_, ev.TimeZone = zones.lookup(tz)
+		}
+	case "DTEND":
+		ev.End, _, err = icsTime(l, zones)
+	case "DURATION":
+		*dur, err = parseICSDuration(l.value)
+	case "RRULE":
+		var r Rule
+		r, err = ParseRule(l.value)
+		ev.Recurrence = append(ev.Recurrence, r)
+	case "EXDATE":
+		ev.ExDates, err = icsTimes(ev.ExDates, l, zones)
+	case "RDATE":
+		err = fmt.Errorf("not supported")
+	case "ATTENDEE":
+		a := Attendee{
+			Email:    strings.TrimPrefix(strings.TrimPrefix(l.value, "mailto:"), "MAILTO:"),
+			Name:     l.params["CN"],
+			Optional: l.params["ROLE"] == "OPT-PARTICIPANT",
+		}
+		switch l.params["PARTSTAT"] {
+		case "ACCEPTED", "DECLINED", "TENTATIVE":
+			a.Response = strings.ToLower(l.params["PARTSTAT"])
+		case "NEEDS-ACTION":
+			a.Response = "needsAction"
+		}
+		ev.Attendees = append(ev.Attendees, a)
+	}
+	return err
+}
+
+// setAlarmProperty sets the field of r for l, a property of a VALARM. It
+// reports false if the alarm has no Reminder form: its TRIGGER is a time,
+// like the placeholder alarms of Apple Calendar, or is relative to the
+// end, or comes after the start.
+func setAlarmProperty(r *Reminder, l icsLine) bool {
+	switch l.name {
+	case "ACTION":
+		if l.value == "EMAIL" {
+			r.Method = "email"
+		}
+	case "TRIGGER":
+		if l.params["VALUE"] == "DATE-TIME" || l.params["RELATED"] == "END" {
+			return false
+		}
+		d, err := parseICSDuration(l.value)
+		if err != nil || d > 0 {
+			return false
+		}
+		r.Minutes = int(-d / time.Minute)
+	}
+	return true
+}
+
+// finishICSEvent fills in the end of ev if it was given by a duration, or
+// not at all.
+func finishICSEvent(ev *Event, dur time.Duration) error {
+	if ev.Start.IsZero() {
+		return fmt.Errorf("VEVENT %q has no DTSTART", ev.Summary)
+	}
+	if ev.End.IsZero() {
+		switch {
+		case dur >= 0:
+			ev.End = ev.Start.Add(dur)
+		case ev.AllDay:
+			ev.End = ev.Start.AddDate(0, 0, 1)
+		default:
+			ev.End = ev.Start
+		}
+	}
+	return nil
+}
+
+// icsTime parses the DATE or DATE-TIME value of l. A DATE-TIME with
+// neither a UTC marker nor a TZID is in the floating zone.
+func icsTime(l icsLine, zones *icsZones) (t time.Time, allDay bool, err error) {
+	if l.params["VALUE"] == "DATE" || len(l.value) == len(icsDateLayout) {
+		t, err = time.Parse(icsDateLayout, l.value)
+		return t, true, err
+	}
+	loc := zones.floating
+	if tz := l.params["TZID"]; tz != "" {
+		loc, _ = zones.lookup(tz)
+	}
+	t, err = parseICSTime(l.value, loc)
+	return t, false, err
+}
+
+// icsTimes appends the comma-separated times of l, like those of an
+// EXDATE, to ts.
+func icsTimes(ts []time.Time, l icsLine, zones *icsZones) ([]time.Time, error) {
+	for _, v := range strings.Split(l.value, ",") {
+		l.value = v
+		t, _, err := icsTime(l, zones)
+		if err != nil {
+			return nil, err
+		}
+		ts = append(ts, t)
+	}
+	return ts, nil
+}
+
+// parseICSTime parses a DATE-TIME or DATE. A DATE-TIME without a trailing
+// Z is in loc, or local time if loc is nil.
+func parseICSTime(v string, loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	switch {
+	case strings.HasSuffix(v, "Z"):
+		return time.Parse(icsUTCLayout, v)
+	case len(v) == len(icsDateLayout):
+		return time.Parse(icsDateLayout, v)
+	default:
+		return time.ParseInLocation(icsLocalLayout, v, loc)
+	}
+}
+
+// parseICSDuration parses a DURATION value like PT1H30M, P1D or -PT15M.
+func parseICSDuration(v string) (time.Duration, error) {
+	s := v
+	sign := time.Duration(1)
+	if rest, ok := strings.CutPrefix(s, "-"); ok {
+		sign, s = -1, rest
+	} else {
+		s = strings.TrimPrefix(s, "+")
+	}
+	s, ok := strings.CutPrefix(s, "P")
+	if !ok || s == "" {
+		return 0, fmt.Errorf("bad duration %q", v)
+	}
+	units := map[byte]time.Duration{
+		'W': 7 * 24 * time.Hour,
+		'D': 24 * time.Hour,
+		'H': time.Hour,
+		'M': time.Minute,
+		'S': time.Second,
+	}
+	var d time.Duration
+	inTime := false
+	for s != "" {
+		if s[0] == 'T' {
+			inTime = true
+			s = s[1:]
+			continue
+		}
+		i := 0
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		if i == 0 || i == len(s) {
+			return 0, fmt.Errorf("bad duration %q", v)
+		}
+		n, _ := strconv.Atoi(s[:i])
+		unit, ok := units[s[i]]
+		// M is minutes only after the T; months aren't allowed.
+		if !ok || (s[i] == 'M' && !inTime) || (inTime && (s[i] == 'W' || s[i] == 'D')) {
+			return 0, fmt.Errorf("bad duration %q", v)
+		}
+		d += time.Duration(n) * unit
+		s = s[i+1:]
+	}
+	return sign * d, nil
+}
+
+var icsTextUnescaper = strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`)
+
+func unescapeICSText(s string) string {
+	return icsTextUnescaper.Replace(s)
+}
+
+var icsTextEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, ",", `\,`, ";", `\;`)
+
+// WriteICS writes evs to w as an iCalendar file.
+func WriteICS(w io.Writer, evs []*Event) error {
+	bw := bufio.NewWriter(w)
+	line := func(s string) {
+		// Fold lines longer than 75 octets, without splitting UTF-8
+		// sequences. Continuation lines start with a space, so they hold
+		// 74 octets of the line.
+		for n := 75; len(s) > n; n = 74 {
+			i := n
+			for i > 0 && s[i]&0xC0 == 0x80 {
+				i--
+			}
+			bw.WriteString(s[:i] + "\r\n ")
+			s = s[i:]
+		}
+		bw.WriteString(s + "\r\n")
+	}
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//jba//calendar//EN")
+	for _, e := range evs {
+		line("BEGIN:VEVENT")
+		if e.UID != "" {
+			line("UID:" + e.UID)
+		}
+		line("DTSTAMP:" + time.Now().UTC().Format(icsUTCLayout))
+		line("DTSTART" + e.icsTime(e.Start))
+		line("DTEND" + e.icsTime(e.End))
+		line("SUMMARY:" + icsTextEscaper.Replace(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION:" + icsTextEscaper.Replace(e.Description))
+		}
+		if e.Location != "" {
+			line("LOCATION:" + icsTextEscaper.Replace(e.Location))
+		}
+		if e.URL != "" {
+			line("URL:" + e.URL)
+		}
+		if e.Status != "" {
+			line("STATUS:" + strings.ToUpper(e.Status))
+		}
+		for _, r := range e.Recurrence {
+			line("RRULE:" + r.String())
+		}
+		for _, t := range e.ExDates {
+			line("EXDATE" + e.icsTime(t))
+		}
+		for _, a := range e.Attendees {
+			var params string
+			if a.Name != "" {
+				// Parameter values can't contain double quotes.
+				params += `;CN="` + strings.ReplaceAll(a.Name, `"`, "'") + `"`
+			}
+			if a.Optional {
+				params += ";ROLE=OPT-PARTICIPANT"
+			}
+			switch a.Response {
+			case "accepted", "declined", "tentative":
+				params += ";PARTSTAT=" + strings.ToUpper(a.Response)
+			case "needsAction":
+				params += ";PARTSTAT=NEEDS-ACTION"
+			}
+			line("ATTENDEE" + params + ":mailto:" + a.Email)
+		}
+		if e.Reminders != nil {
+			for _, r := range e.Reminders.Overrides {
+				action := "DISPLAY"
+				if r.Method == "email" {
+					action = "EMAIL"
+				}
+				line("BEGIN:VALARM")
+				line("ACTION:" + action)
+				line(fmt.Sprintf("TRIGGER:-PT%dM", r.Minutes))
+				if action == "DISPLAY" {
+					line("DESCRIPTION:" + icsTextEscaper.Replace(e.Summary))
+				}
+				line("END:VALARM")
+			}
+		}
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return bw.Flush()
+}
+
+// icsTime returns the parameters and value of a DTSTART or DTEND for t,
+// starting with the ; or : that follows the property name.
+func (e *Event) icsTime(t time.Time) string {
+	if e.AllDay {
+		return ";VALUE=DATE:" + t.Format(icsDateLayout)
+	}
+	if e.TimeZone != "" {
+		if loc, err := time.LoadLocation(e.TimeZone); err == nil {
+			return ";TZID=" + e.TimeZone + ":" + t.In(loc).Format(icsLocalLayout)
+		}
+	}
+	return ":" + t.UTC().Format(icsUTCLayout)
+}
@@ -0,0 +1,125 @@
+package calendar
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// icsZones finds the time zones named by TZID parameters.
+type icsZones struct {
+	floating *time.Location            // for times with no zone
+	defined  map[string]*time.Location // from VTIMEZONE components
+
+	// State while reading a VTIMEZONE.
+	tzid, part string
+}
+
+// add records the property l of a VTIMEZONE. Only the UTC offset of its
+// standard time is kept, so a zone that LoadLocation doesn't know is a
+// fixed zone, off by an hour during daylight saving time.
+func (z *icsZones) add(l icsLine) {
+	switch l.name {
+	case "BEGIN":
+		z.part = l.value
+	case "END":
+		z.part = ""
+	case "TZID":
+		z.tzid = l.value
+	case "TZOFFSETTO":
+		if z.part != "STANDARD" || z.tzid == "" {
+			return
+		}
+		if off, err := parseICSOffset(l.value); err == nil {
+			if z.defined == nil {
+				z.defined = map[string]*time.Location{}
+			}
+			z.defined[z.tzid] = time.FixedZone(z.tzid, off)
+		}
+	}
+}
+
+// lookup returns the location for tzid, and its IANA name if it has one.
+// Names that LoadLocation doesn't know may be Windows zone names, as
+// Outlook writes, or be defined by a VTIMEZONE; failing those, times are
+// in the floating zone.
+func (z *icsZones) lookup(tzid string) (*time.Location, string) {
+	if loc, err := time.LoadLocation(tzid); err == nil {
+		return loc, tzid
+	}
+	if name, ok := windowsZones[tzid]; ok {
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc, name
+		}
+	}
+	if loc, ok := z.defined[tzid]; ok {
+		return loc, ""
+	}
+	return z.floating, ""
+}
+
+// parseICSOffset parses a UTC offset like -0500 or +053000, in seconds.
+func parseICSOffset(v string) (int, error) {
+	if len(v) != 5 && len(v) != 7 || v[0] != '+' && v[0] != '-' {
+		return 0, fmt.Errorf("bad UTC offset %q", v)
+	}
+	n := 0
+	for i, unit := range []int{3600, 60, 1}[:len(v)/2] {
+		d, err := strconv.Atoi(v[1+2*i : 3+2*i])
+		if err != nil {
+			return 0, fmt.Errorf("bad UTC offset %q", v)
+		}
+		n += d * unit
+	}
+	if strings.HasPrefix(v, "-") {
+		n = -n
+	}
+	return n, nil
+}
+
+// windowsZones maps the Windows names of common time zones to IANA names.
+var windowsZones = map[string]string{
+	"UTC":                            "UTC",
+	"GMT Standard Time":              "Europe/London",
+	"Greenwich Standard Time":        "Atlantic/Reykjavik",
+	"W. Europe Standard Time":        "Europe/Berlin",
+	"Central Europe Standard Time":   "Europe/Budapest",
+	"Central European Standard Time": "Europe/Warsaw",
+	"Romance Standard Time":          "Europe/Paris",
+	"E. Europe Standard Time":        "Europe/Chisinau",
+	"FLE Standard Time":              "Europe/Kiev",
+	"GTB Standard Time":              "Europe/Bucharest",
+	"Turkey Standard Time":           "Europe/Istanbul",
+	"Russian Standard Time":          "Europe/Moscow",
+	"Israel Standard Time":           "Asia/Jerusalem",
+	"Egypt Standard Time":            "Africa/Cairo",
+	"South Africa Standard Time":     "Africa/Johannesburg",
+	"Arabian Standard Time":          "Asia/Dubai",
+	"India Standard Time":            "Asia/Kolkata",
+	"SE Asia Standard Time":          "Asia/Bangkok",
+	"Singapore Standard Time":        "Asia/Singapore",
+	"China Standard Time":            "Asia/Shanghai",
+	"Taipei Standard Time":           "Asia/Taipei",
+	"Tokyo Standard Time":            "Asia/Tokyo",
+	"Korea Standard Time":            "Asia/Seoul",
+	"W. Australia Standard Time":     "Australia/Perth",
+	"Cen. Australia Standard Time":   "Australia/Adelaide",
+	"E. Australia Standard Time":     "Australia/Brisbane",
+	"AUS Eastern Standard Time":      "Australia/Sydney",
+	"New Zealand Standard Time":      "Pacific/Auckland",
+	"Hawaiian Standard Time":         "Pacific/Honolulu",
+	"Alaskan Standard Time":          "America/Anchorage",
+	"Pacific Standard Time":          "America/Los_Angeles",
+	"US Mountain Standard Time":      "America/Phoenix",
+	"Mountain Standard Time":         "America/Denver",
+	"Central Standard Time":          "America/Chicago",
+	"Central Standard Time (Mexico)": "America/Mexico_City",
+	"Canada Central Standard Time":   "America/Regina",
+	"Eastern Standard Time":          "America/New_York",
+	"SA Pacific Standard Time":       "America/Bogota",
+	"Atlantic Standard Time":         "America/Halifax",
+	"Newfoundland Standard Time":     "America/St_Johns",
+	"Argentina Standard Time":        "America/Buenos_Aires",
+	"E. South America Standard Time": "America/Sao_Paulo",
+}
@@ -0,0 +1,130 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	api "google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// A Call is one change to a calendar, as seen by Middleware.
+type Call struct {
+	Op          string // "insert", "patch", "delete" or "move"
+	CalendarID  string
+	EventID     string     // for patch, delete and move
+	Destination string     // for move, the calendar to move the event to
+	Event       *api.Event // the event to insert, or the patch; Middleware may replace it
+	Result      *api.Event // after a successful insert, patch or move, the event as the server has it
+	SendUpdates string     // for insert, whom the server emails about the event: "all", "externalOnly" or "none" (the default)
+}
+
+// Middleware wraps the calls a Client makes. It can inspect or change
+// call before calling next, which makes the call, and see call.Result
+// and the error afterwards. Returning without calling next fails the
+// call with the returned error.
+type Middleware func(ctx context.Context, call *Call, next func(context.Context) error) error
+
+// WithMiddleware adds middleware to the Client, as Client.Use does.
+func WithMiddleware(mw ...Middleware) Option {
+	return func(c *config) { c.middleware = append(c.middleware, mw...) }
+}
+
+// Use adds middleware to c. The first middleware added is the outermost:
+// it sees each call first and its result last.
+func (c *Client) Use(mw ...Middleware) {
+	c.middleware = append(c.middleware, mw...)
+}
+
+// run passes call through c's middleware to do.
+func (c *Client) run(ctx context.Context, call *Call, do func(context.Context) error) error {
+	next := do
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		mw, inner := c.middleware[i], next
+		next = func(ctx context.Context) error { return mw(ctx, call, inner) }
+	}
+	return next(ctx)
+}
+
+// InsertEvent inserts ev into the calendar, and returns the event as the
+// server has it. A server error is not retried unless ev has an ID,
+// since the event may have been inserted.
+func (c *Client) InsertEvent(ctx context.Context, calID string, ev *api.Event) (*api.Event, error) {
+	call := &Call{Op: "insert", CalendarID: calID, Event: ev}
+	err := c.run(ctx, call, func(ctx context.Context) error {
+		retryable := rateLimited
+		if call.Event.Id != "" {
+			retryable = temporary
+		}
+		return c.retry(ctx, retryable, func() error {
+			ic := c.svc.Events.Insert(call.CalendarID, call.Event).Context(ctx)
+			if call.SendUpdates != "" {
+				ic.SendUpdates(call.SendUpdates)
+			}
+			var err error
+			call.Result, err = ic.Do()
+			return err
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return call.Result, nil
+}
+
+// PatchEvent changes the fields of an event that are set in patch, and
+// returns the event as the server has it.
+func (c *Client) PatchEvent(ctx context.Context, calID, eventID string, patch *api.Event) (*api.Event, error) {
+	call := &Call{Op: "patch", CalendarID: calID, EventID: eventID, Event: patch}
+	err := c.run(ctx, call, func(ctx context.Context) error {
+		return c.retry(ctx, temporary, func() error {
+			var err error
+			call.Result, err = c.svc.Events.Patch(call.CalendarID, call.EventID, call.Event).Context(ctx).Do()
+			return err
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return call.Result, nil
+}
+
+// MoveEvent moves an event to the calendar destID, and returns the event
+// as the server has it. Its ID doesn't change. A server error is not
+// retried, since the event may have been moved.
+func (c *Client) MoveEvent(ctx context.Context, calID, eventID, destID string) (*api.Event, error) {
+	call := &Call{Op: "move", CalendarID: calID, EventID: eventID, Destination: destID}
+	err := c.run(ctx, call, func(ctx context.Context) error {
+		return c.retry(ctx, rateLimited, func() error {
+			var err error
+			call.Result, err = c.svc.Events.Move(call.CalendarID, call.EventID, call.Destination).Context(ctx).Do()
+			return err
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return call.Result, nil
+}
+
+// DeleteEvent deletes an event. A server error may come after the event
+// was deleted, so if a retry after one finds the event gone, the delete
+// has succeeded.
+func (c *Client) DeleteEvent(ctx context.Context, calID, eventID string) error {
+	call := &Call{Op: "delete", CalendarID: calID, EventID: eventID}
+	return c.run(ctx, call, func(ctx context.Context) error {
+		serverErr := false
+		return c.retry(ctx, temporary, func() error {
+			err := c.svc.Events.Delete(call.CalendarID, call.EventID).Context(ctx).Do()
+			var gerr *googleapi.Error
+			if errors.As(err, &gerr) {
+				if serverErr && (gerr.Code == http.StatusNotFound || gerr.Code == http.StatusGone) {
+					return nil
+				}
+				serverErr = serverErr || gerr.Code >= 500
+			}
+			return err
+		})
+	})
+}
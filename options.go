@@ -0,0 +1,174 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	api "google.golang.org/api/calendar/v3"
+	"google.golang.org/api/option"
+	htrans "google.golang.org/api/transport/http"
+)
+
+// An Option configures NewClient and NewHTTPClient.
+type Option func(*config)
+
+type config struct {
+	credsJSON  []byte
+	credsFile  string
+	scopes     []string
+	httpClient *http.Client
+	limiter    RateLimiter
+	attempts   int
+	backoff    time.Duration
+	hook       RequestHook
+	middleware []Middleware
+}
+
+// A RateLimiter delays requests. *rate.Limiter from golang.org/x/time/rate
+// is one.
+type RateLimiter interface {
+	// Wait blocks until a request may be made, or ctx is done.
+	Wait(ctx context.Context) error
+}
+
+// A RequestHook is called after each HTTP request with the response or
+// error and how long the request took. It must not read res.Body.
+type RequestHook func(req *http.Request, res *http.Response, err error, d time.Duration)
+
+// WithCredentialsJSON sets the credentials: a service account key, or
+// authorized user JSON like that written by "cal auth".
+func WithCredentialsJSON(data []byte) Option {
+	return func(c *config) { c.credsJSON = data }
+}
+
+// WithCredentialsFile reads the credentials from a file.
+func WithCredentialsFile(filename string) Option {
+	return func(c *config) { c.credsFile = filename }
+}
+
+// WithScopes sets the OAuth scopes to request. The default is
+// api.CalendarScope.
+func WithScopes(scopes ...string) Option {
+	return func(c *config) { c.scopes = scopes }
+}
+
+// WithHTTPClient makes requests with hc, which must already be
+// authorized. Credentials and scopes are then ignored.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *config) { c.httpClient = hc }
+}
+
+// WithRateLimiter makes every request wait on l first.
+func WithRateLimiter(l RateLimiter) Option {
+	return func(c *config) { c.limiter = l }
+}
+
+// WithRetry sets how many times, counting the first, a Client tries a
+// request whose error may be temporary, and how long it waits before the
+// first retry. The wait doubles after each retry. The default is 4
+// attempts starting at one second. One attempt turns retrying off.
+//
+// Listing, patching and deleting are retried on rate limiting and server
+// errors. Inserting and moving are retried only on rate limiting, as a
+// server error may come after the change was made; see
+// Client.InsertEvent. A delete retried after a server error that finds
+// the event gone counts as done.
+func WithRetry(attempts int, backoff time.Duration) Option {
+	return func(c *config) { c.attempts, c.backoff = attempts, backoff }
+}
+
+// WithRequestHook calls h after every HTTP request, for logging or
+// metrics.
+func WithRequestHook(h RequestHook) Option {
+	return func(c *config) { c.hook = h }
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{
+		scopes:   []string{api.CalendarScope},
+		attempts: 4,
+		backoff:  time.Second,
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+// NewClient returns a Client configured by opts. Unless WithHTTPClient is
+// given, it needs WithCredentialsJSON or WithCredentialsFile.
+func NewClient(ctx context.Context, opts ...Option) (*Client, error) {
+	cfg := newConfig(opts)
+	hc, err := cfg.newHTTPClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	svc, err := api.NewService(ctx, option.WithHTTPClient(hc))
+	if err != nil {
+		return nil, err
+	}
+	return &Client{svc: svc, attempts: max(cfg.attempts, 1), backoff: cfg.backoff, middleware: cfg.middleware}, nil
+}
+
+// NewHTTPClient returns the authorized HTTP client that NewClient would
+// use, for callers that make their own services from it.
+func NewHTTPClient(ctx context.Context, opts ...Option) (*http.Client, error) {
+	return newConfig(opts).newHTTPClient(ctx)
+}
+
+func (cfg *config) newHTTPClient(ctx context.Context) (*http.Client, error) {
+	hc := cfg.httpClient
+	if hc == nil {
+		var copts []option.ClientOption
+		switch {
+		case cfg.credsJSON != nil:
+			copts = append(copts, option.WithCredentialsJSON(cfg.credsJSON))
+		case cfg.credsFile != "":
+			copts = append(copts, option.WithCredentialsFile(cfg.credsFile))
+		default:
+			return nil, errors.New("calendar: no credentials")
+		}
+		copts = append(copts, option.WithScopes(cfg.scopes...))
+		var err error
+		hc, _, err = htrans.NewClient(ctx, copts...)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if cfg.limiter == nil && cfg.hook == nil {
+		return hc, nil
+	}
+	base := hc.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	c := *hc
+	c.Transport = &hookTransport{base: base, limiter: cfg.limiter, hook: cfg.hook}
+	return &c, nil
+}
+
+// hookTransport applies a RateLimiter and a RequestHook.
+type hookTransport struct {
+	base    http.RoundTripper
+	limiter RateLimiter
+	hook    RequestHook
+}
+
+func (t *hookTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.limiter != nil {
+		if err := t.limiter.Wait(req.Context()); err != nil {
+			if req.Body != nil {
+				req.Body.Close()
+			}
+			return nil, err
+		}
+	}
+	start := time.Now()
+	res, err := t.base.RoundTrip(req)
+	if t.hook != nil {
+		t.hook(req, res, err, time.Since(start))
+	}
+	return res, err
+}